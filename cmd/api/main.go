@@ -9,6 +9,17 @@
 
 // @tag.name subscription
 // @tag.description Subscription management operations
+
+// @tag.name privacy
+// @tag.description Data-subject export and erasure requests
+
+// @tag.name webhooks
+// @tag.description Delivery events reported by the mail provider
+
+// @tag.name admin
+// @tag.description Support operations on subscriptions
+
+// @securityDefinitions.basic BasicAuth
 package main
 
 import (
@@ -18,17 +29,30 @@ import (
 	"Weather-API-Application/internal/handler"
 	"Weather-API-Application/internal/infrastructure/database"
 	"Weather-API-Application/internal/infrastructure/repository"
+	"Weather-API-Application/internal/lifecycle"
 	"Weather-API-Application/internal/logger"
 	"Weather-API-Application/internal/server"
+	"Weather-API-Application/internal/services/bulk_service"
+	"Weather-API-Application/internal/services/capture_service"
+	"Weather-API-Application/internal/services/delivery_service"
+	"Weather-API-Application/internal/services/janitor_service"
+	"Weather-API-Application/internal/services/privacy_service"
 	"Weather-API-Application/internal/services/scheduler_service"
 	"Weather-API-Application/internal/services/subscription_service"
+	"Weather-API-Application/internal/services/suppression_service"
 	"Weather-API-Application/internal/services/weather_service"
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // quiet hours resolve IANA timezones even on images without zoneinfo
 )
 
 func main() {
-	ctx := context.Background()
+	// Cancelled on SIGINT or SIGTERM, which stops background work from taking on anything new
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// Load config
 	cfg, err := config.NewConfigFromEnv()
@@ -43,15 +67,44 @@ func main() {
 		logger.Fatal(ctx, err)
 	}
 
-	// Initialize email client
-	emailClient := client.NewEmailClient(cfg)
-
 	// Initialize repositories
 	subscriptionRepository := repository.NewSubscriptionRepository(db)
+	suppressionRepository := repository.NewSuppressionRepository(db)
+	deliveryRepository := repository.NewDeliveryRepository(db)
+	schedulerRepository := repository.NewSchedulerRepository(db)
+	captureRepository := repository.NewCaptureRepository(db)
+
+	if cfg.EmailTemplateDir != "" {
+		if err := client.LoadTemplates(cfg.EmailTemplateDir); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to load email templates: %w", err))
+		}
+		logger.Info(ctx, "Email templates loaded from "+cfg.EmailTemplateDir)
+	}
+
+	// Initialize email client; every email goes through the suppression list, the outcome of
+	// scheduled and on-demand updates is written to the delivery log, and dry runs are captured
+	// right before the mail server
+	suppressionService := suppression_service.NewSuppressionService(suppressionRepository, cfg)
+	deliveryService := delivery_service.NewDeliveryService(deliveryRepository, cfg)
+	captureService := capture_service.NewCaptureService(captureRepository, cfg)
+	emailClient := deliveryService.Track(suppressionService.Guard(captureService.Route(client.NewEmailClient(cfg))))
+	if cfg.DryRun {
+		logger.Info(ctx, "Dry run, emails are captured instead of sent")
+	}
 
 	// Initialize services
-	schedulerService := scheduler_service.NewSchedulerService(subscriptionRepository, *emailClient, cfg)
+	schedulerService := scheduler_service.NewSchedulerService(subscriptionRepository, emailClient, cfg).
+		WithSuppressions(suppressionService).
+		WithDeliveries(deliveryService).
+		WithControl(schedulerRepository)
 	subscriptionService := subscription_service.NewSubscriptionService(subscriptionRepository, emailClient, cfg).WithScheduler(schedulerService)
+	janitorService := janitor_service.NewJanitorService(subscriptionRepository, cfg)
+	privacyService := privacy_service.NewPrivacyService(subscriptionRepository, deliveryRepository, emailClient, cfg)
+
+	// Tokens issued before hashing at rest was introduced are stored raw; hash them before serving links
+	if err := subscriptionService.HashLegacyTokens(ctx); err != nil {
+		logger.Fatal(ctx, err)
+	}
 
 	// Initialize server
 	srvr := server.NewServer(cfg)
@@ -62,14 +115,50 @@ func main() {
 	weatherSvc := weather_service.NewService(weatherAPIClient)
 	weatherHandler := handler.NewWeatherHandler(weatherSvc)
 	subscriptionHandler := handler.NewSubscriptionHandler(cfg, subscriptionService)
+	privacyHandler := handler.NewPrivacyHandler(cfg, privacyService)
 	weatherHandler.RegisterRoutes(srvr.Router)
 	subscriptionHandler.RegisterRoutes(srvr.Router)
+	privacyHandler.RegisterRoutes(srvr.Router)
+	if cfg.WebhookSecret != "" {
+		handler.NewWebhookHandler(cfg, suppressionService).RegisterRoutes(srvr.Router)
+	} else {
+		logger.Info(ctx, "Email event webhook disabled, WEBHOOK_SECRET is not set")
+	}
+	bulkService := bulk_service.NewBulkService(subscriptionRepository, emailClient, cfg).WithScheduler(schedulerService)
+	if cfg.AdminEnabled() {
+		handler.NewAdminHandler(cfg, subscriptionService, schedulerService, bulkService, suppressionService, deliveryService, captureService).RegisterRoutes(srvr.Router)
+	} else {
+		logger.Info(ctx, "Admin API and metrics disabled, ADMIN_USER and ADMIN_PASSWORD are not set")
+	}
 
-	// Start scheduler for confirmed subscriptions
-	if err := schedulerService.StartScheduler(ctx); err != nil {
-		logger.Fatal(ctx, fmt.Errorf("failed to start subscription scheduler: %w", err))
+	// Dispatch due deliveries of confirmed subscriptions, and act on changes made elsewhere, such as by
+	// another replica or by hand, as soon as the database announces them
+	schedulerService.StartScheduler(ctx)
+	changes := database.NewListener(cfg.GetDSN(), scheduler_service.ScheduleChangesChannel)
+	if cfg.SchedulerListen {
+		changes.Start(ctx, func(payload string) { schedulerService.HandleChange(ctx, payload) })
 	}
 
+	// Purge pending subscriptions that were never confirmed
+	janitorService.Start(ctx)
+
 	// Run API server
-	srvr.Run(ctx)
+	srvr.Start(ctx)
+
+	// On a signal, let in-flight deliveries finish, drain HTTP requests and with them imports, let the batch
+	// of import confirmations in flight finish, then close what they used.
+	// Emails are sent over a new SMTP connection each, so there is no mail connection to close.
+	shutdown := lifecycle.NewManager()
+	shutdown.Add("scheduler", cfg.ShutdownDeliveryTimeout, schedulerService.Drain)
+	shutdown.Add("schedule change listener", cfg.ShutdownTimeout, changes.Wait)
+	shutdown.Add("http server", cfg.ShutdownTimeout, srvr.Shutdown)
+	shutdown.Add("import confirmations", cfg.ShutdownDeliveryTimeout, bulkService.Drain)
+	shutdown.Add("database", cfg.ShutdownTimeout, lifecycle.Closer(db))
+
+	<-ctx.Done()
+	stop() // a second signal terminates right away
+	if err := shutdown.Shutdown(context.Background()); err != nil {
+		os.Exit(1)
+	}
+	logger.Info(context.Background(), "Server exiting")
 }
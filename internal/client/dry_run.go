@@ -0,0 +1,17 @@
+package client
+
+import "context"
+
+type dryRunKey struct{}
+
+// WithDryRun marks emails sent with the returned context as a dry run: they go through the whole pipeline
+// but are captured instead of reaching the mail server.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx was marked by WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
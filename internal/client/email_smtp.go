@@ -2,15 +2,18 @@ package client
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"net/mail"
 	"net/smtp"
+	"strings"
 
 	"Weather-API-Application/internal/config"
 	"Weather-API-Application/internal/logger"
 	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/templates"
+	"Weather-API-Application/internal/utils/clock"
 )
 
 // SmtpSender abstracts smtp.SendMail for testability.
@@ -26,32 +29,74 @@ func (smtpSender) SendMail(addr string, a smtp.Auth, from string, to []string, m
 
 type EmailClient struct {
 	From     string
+	FromName string
+	ReplyTo  string
 	Password string
 	Host     string
 	Port     string
 	sender   SmtpSender
+	clock    clock.Clock
 }
 
 func NewEmailClient(cfg *config.Config) *EmailClient {
-	return &EmailClient{From: cfg.EmailClientFrom, Password: cfg.EmailClientPassword, Host: cfg.EmailClientHost, Port: cfg.EmailClientPort, sender: smtpSender{}}
+	return NewEmailClientWithSender(cfg, smtpSender{})
 }
 
 // NewEmailClientWithSender allows injecting a custom SmtpSender (useful for tests).
 func NewEmailClientWithSender(cfg *config.Config, sender SmtpSender) *EmailClient {
-	return &EmailClient{From: cfg.EmailClientFrom, Password: cfg.EmailClientPassword, Host: cfg.EmailClientHost, Port: cfg.EmailClientPort, sender: sender}
+	return &EmailClient{
+		From:     cfg.EmailClientFrom,
+		FromName: cfg.EmailClientFromName,
+		ReplyTo:  cfg.EmailClientReplyTo,
+		Password: cfg.EmailClientPassword,
+		Host:     cfg.EmailClientHost,
+		Port:     cfg.EmailClientPort,
+		sender:   sender,
+		clock:    clock.Real(),
+	}
+}
+
+// Header is an additional message header, e.g. List-Unsubscribe.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Body is the content of an email: an HTML part and its plain-text alternative. Either may be empty,
+// in which case the message has the other part only.
+type Body struct {
+	HTML string
+	Text string
 }
 
+// ErrSuppressed is returned instead of sending to an address on the suppression list.
+var ErrSuppressed = errors.New("recipient is suppressed")
+
+// ErrSkipped is recorded for an email no longer wanted when it comes to be sent, such as a retry for
+// a subscription paused since the first attempt.
+var ErrSkipped = errors.New("email no longer wanted")
+
 // Client defines methods for sending emails (used by services).
 type Client interface {
-	SendEmail(ctx context.Context, to, subject, body string) error
+	SendEmail(ctx context.Context, to, subject string, body Body, headers ...Header) error
 }
 
-// SendEmail sends an email using SMTP.
-func (c *EmailClient) SendEmail(ctx context.Context, to, subject, body string) error {
-	msg := []byte("To: " + to + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n" +
-		"\r\n" + body)
+// SendEmail sends an email using SMTP, from SMTP_FROM under SMTP_FROM_NAME and with replies to SMTP_REPLY_TO.
+func (c *EmailClient) SendEmail(ctx context.Context, to, subject string, body Body, headers ...Header) error {
+	message := &Message{
+		From:      mail.Address{Name: c.FromName, Address: c.From},
+		ReplyTo:   c.ReplyTo,
+		To:        to,
+		Subject:   subject,
+		Date:      c.clock.Now(),
+		MessageID: newMessageID(c.From),
+		Headers:   headers,
+		Body:      body,
+	}
+	msg, err := message.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
 
 	auth := smtp.PlainAuth("", c.From, c.Password, c.Host)
 
@@ -61,39 +106,229 @@ func (c *EmailClient) SendEmail(ctx context.Context, to, subject, body string) e
 
 	logger.Info(ctx, "Email sent successfully",
 		slog.String("to", to),
-		slog.String("subject", subject))
+		slog.String("subject", subject),
+		slog.String("message_id", message.MessageID))
 	return nil
 }
 
-// SendUpdate fetches current weather for the subscription city and emails the user.
-func SendUpdate(ctx context.Context, apiKey string, sub *model.Subscription, emailClient Client) error {
-	if apiKey == "" {
-		return fmt.Errorf("weather API key is missing in config")
+// UnsubscribeHeaders returns the RFC 2369 / RFC 8058 headers enabling one-click unsubscribe via a POST to
+// oneClickURL. Without a URL no headers are returned.
+func UnsubscribeHeaders(oneClickURL string) []Header {
+	if oneClickURL == "" {
+		return nil
 	}
+	return []Header{
+		{Name: "List-Unsubscribe", Value: "<" + oneClickURL + ">"},
+		{Name: "List-Unsubscribe-Post", Value: "List-Unsubscribe=One-Click"},
+	}
+}
+
+// PauseLink is a "pause for N days" link included in update emails.
+type PauseLink struct {
+	Days int
+	URL  string
+}
+
+// UpdateLinks holds the subscription management links included in update emails. Unsubscribe is the
+// confirmation page shown in the body; OneClickUnsubscribe is the List-Unsubscribe target mail clients post to.
+type UpdateLinks struct {
+	Unsubscribe         string
+	OneClickUnsubscribe string
+	Pause               []PauseLink
+}
+
+// weatherContent is the data of update emails and of each city of a digest. Templates show City's Weather
+// and its management Links; a nil Weather means the weather of the city could not be fetched.
+type weatherContent struct {
+	City    string
+	Weather *model.WeatherAPIResponse
+	Links   UpdateLinks
+}
+
+// observedSummary sums up the weather observed during quiet hours for a catch-up summary.
+type observedSummary struct {
+	MinTempC, MaxTempC       float64
+	MinHumidity, MaxHumidity float64
+	Conditions               string
+}
+
+// catchUpContent is the data of catch-up summaries. Window is the quiet hours, e.g. "22:00–07:00", and
+// Observed is nil when nothing was observed.
+type catchUpContent struct {
+	weatherContent
+	Window   string
+	Observed *observedSummary
+}
+
+// digestContent is the data of digests.
+type digestContent struct {
+	Sections       []weatherContent
+	UnsubscribeAll string
+}
 
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no", apiKey, sub.City)
+// linkContent is the data of emails that carry a single link, such as confirmations and privacy requests.
+type linkContent struct {
+	URL string
+}
 
-	resp, err := http.Get(url)
+// SendConfirmation emails the link that confirms a subscription.
+func SendConfirmation(ctx context.Context, email, confirmURL string, emailClient Client) error {
+	body, err := render(templates.Confirm, linkContent{URL: confirmURL})
 	if err != nil {
-		return fmt.Errorf("invalid request: failed to fetch weather data: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	return emailClient.SendEmail(ctx, email, config.ConfirmSubject, body)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("city not found: failed to fetch weather data: %s", resp.Status)
+// SendPrivacyExport emails the link that downloads all data held about an address.
+func SendPrivacyExport(ctx context.Context, email, exportURL string, emailClient Client) error {
+	body, err := render(templates.PrivacyExport, linkContent{URL: exportURL})
+	if err != nil {
+		return err
 	}
+	return emailClient.SendEmail(ctx, email, config.PrivacyExportSubject, body)
+}
 
-	var weatherApiResp model.WeatherAPIResponse
-	if err = json.NewDecoder(resp.Body).Decode(&weatherApiResp); err != nil {
-		return fmt.Errorf("failed to decode weather data: %w", err)
+// SendPrivacyErase emails the link that erases all data held about an address.
+func SendPrivacyErase(ctx context.Context, email, eraseURL string, emailClient Client) error {
+	body, err := render(templates.PrivacyErase, linkContent{URL: eraseURL})
+	if err != nil {
+		return err
 	}
+	return emailClient.SendEmail(ctx, email, config.PrivacyEraseSubject, body)
+}
 
-	weatherMailText := fmt.Sprintf(`Weather for %s:<br>- temperature: %.1f°C<br>- humidity: %.0f%%<br>- description: %s`,
-		sub.City, weatherApiResp.Current.TempC, weatherApiResp.Current.Humidity, weatherApiResp.Current.Condition.Text)
+// SendUpdate emails the weather of the subscription city to the user
+// with management links in the body and one-click unsubscribe headers.
+func SendUpdate(ctx context.Context, sub *model.Subscription, weather *model.WeatherAPIResponse, links UpdateLinks, emailClient Client) error {
+	body, err := render(templates.Update, weatherContent{City: sub.City, Weather: weather, Links: links})
+	if err != nil {
+		return err
+	}
 	subject := fmt.Sprintf("%s forecast", sub.City)
 
-	if err := emailClient.SendEmail(ctx, sub.Email, subject, weatherMailText); err != nil {
+	if err := emailClient.SendEmail(ctx, sub.Email, subject, body, UnsubscribeHeaders(links.OneClickUnsubscribe)...); err != nil {
 		return fmt.Errorf("failed to send email to %s for city %s: %w", sub.Email, sub.City, err)
 	}
 	return nil
 }
+
+// DigestSection is one city of a digest email together with its weather and management links.
+// A nil Weather means the weather of the city could not be fetched.
+type DigestSection struct {
+	Subscription *model.Subscription
+	Weather      *model.WeatherAPIResponse
+	Links        UpdateLinks
+}
+
+// SendDigest emails a single message with a section per city. Cities without weather are
+// reported inline; the digest fails only if no city has any. unsubscribeAllURL is shown in the body,
+// oneClickURL is the List-Unsubscribe target.
+func SendDigest(ctx context.Context, email string, sections []DigestSection, unsubscribeAllURL, oneClickURL string, emailClient Client) error {
+	content := digestContent{UnsubscribeAll: unsubscribeAllURL}
+	var (
+		cities    []string
+		available int
+	)
+	for _, section := range sections {
+		city := section.Subscription.City
+		cities = append(cities, city)
+		content.Sections = append(content.Sections, weatherContent{City: city, Weather: section.Weather, Links: section.Links})
+		if section.Weather != nil {
+			available++
+		}
+	}
+	if available == 0 {
+		return fmt.Errorf("no weather data for any city in digest for %s", email)
+	}
+
+	body, err := render(templates.Digest, content)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("Weather digest: %s", strings.Join(cities, ", "))
+
+	if err := emailClient.SendEmail(ctx, email, subject, body, UnsubscribeHeaders(oneClickURL)...); err != nil {
+		return fmt.Errorf("failed to send digest to %s: %w", email, err)
+	}
+	return nil
+}
+
+// SendCatchUpSummary emails a summary of the weather observed while the subscription was in quiet hours,
+// followed by the current weather. It replaces the regular update of the first slot after the window.
+func SendCatchUpSummary(ctx context.Context, sub *model.Subscription, weather *model.WeatherAPIResponse, observed []*model.WeatherAPIResponse, links UpdateLinks, emailClient Client) error {
+	content := catchUpContent{weatherContent: weatherContent{City: sub.City, Weather: weather, Links: links}}
+	if sub.QuietHours != nil {
+		content.Window = fmt.Sprintf("%s–%s", sub.QuietHours.Start, sub.QuietHours.End)
+	}
+	if len(observed) > 0 {
+		summary := &observedSummary{
+			MinTempC: observed[0].Current.TempC, MaxTempC: observed[0].Current.TempC,
+			MinHumidity: observed[0].Current.Humidity, MaxHumidity: observed[0].Current.Humidity,
+		}
+		var conditions []string
+		seen := make(map[string]bool)
+		for _, o := range observed {
+			summary.MinTempC, summary.MaxTempC = min(summary.MinTempC, o.Current.TempC), max(summary.MaxTempC, o.Current.TempC)
+			summary.MinHumidity, summary.MaxHumidity = min(summary.MinHumidity, o.Current.Humidity), max(summary.MaxHumidity, o.Current.Humidity)
+			if c := o.Current.Condition.Text; !seen[c] {
+				seen[c] = true
+				conditions = append(conditions, c)
+			}
+		}
+		summary.Conditions = strings.Join(conditions, ", ")
+		content.Observed = summary
+	}
+
+	body, err := render(templates.CatchUp, content)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("%s catch-up summary", sub.City)
+
+	if err := emailClient.SendEmail(ctx, sub.Email, subject, body, UnsubscribeHeaders(links.OneClickUnsubscribe)...); err != nil {
+		return fmt.Errorf("failed to send catch-up summary to %s for city %s: %w", sub.Email, sub.City, err)
+	}
+	return nil
+}
+
+// emailTemplates renders every email; LoadTemplates replaces the embedded defaults.
+var emailTemplates = templates.Default()
+
+// LoadTemplates replaces the embedded email templates with those in dir; see templates.Load. Every email
+// is rendered with sample data first, so that a template using data that does not exist fails here
+// rather than when the email is sent. It is meant to be called on startup, before any email is sent.
+func LoadTemplates(dir string) error {
+	set, err := templates.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	weather := &model.WeatherAPIResponse{}
+	links := UpdateLinks{Unsubscribe: "https://example.com/unsubscribe", Pause: []PauseLink{{Days: 1, URL: "https://example.com/pause"}}}
+	section := weatherContent{City: "Kyiv", Weather: weather, Links: links}
+	samples := map[string]any{
+		templates.Confirm:       linkContent{URL: "https://example.com/confirm"},
+		templates.Update:        section,
+		templates.CatchUp:       catchUpContent{weatherContent: section, Window: "22:00–07:00", Observed: &observedSummary{}},
+		templates.Digest:        digestContent{Sections: []weatherContent{section, {City: "Lviv", Links: links}}, UnsubscribeAll: "https://example.com/unsubscribe"},
+		templates.PrivacyExport: linkContent{URL: "https://example.com/export"},
+		templates.PrivacyErase:  linkContent{URL: "https://example.com/erase"},
+	}
+	for _, name := range templates.Names {
+		if _, _, err := set.Render(name, samples[name]); err != nil {
+			return err
+		}
+	}
+
+	emailTemplates = set
+	return nil
+}
+
+func render(name string, data any) (Body, error) {
+	html, text, err := emailTemplates.Render(name, data)
+	if err != nil {
+		return Body{}, err
+	}
+	return Body{HTML: html, Text: text}, nil
+}
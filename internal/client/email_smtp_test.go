@@ -0,0 +1,195 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/templates"
+	"Weather-API-Application/internal/utils/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSender struct {
+	msg []byte
+}
+
+func (s *recordingSender) SendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	s.msg = msg
+	return nil
+}
+
+func newTestClient() (*EmailClient, *recordingSender) {
+	sender := &recordingSender{}
+	cfg := &config.Config{EmailClientFrom: "weather@example.com", EmailClientHost: "localhost", EmailClientPort: "25"}
+	return NewEmailClientWithSender(cfg, sender), sender
+}
+
+// parts returns the content type and decoded content of each part of a message.
+func parts(t *testing.T, raw []byte) map[string]string {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		content, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		require.NoError(t, err)
+		return map[string]string{mediaType: string(content)}
+	}
+
+	result := make(map[string]string)
+	var order []string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		partType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "quoted-printable", part.Header.Get("Content-Transfer-Encoding"))
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		result[partType] = string(content)
+		order = append(order, partType)
+	}
+	assert.Equal(t, []string{"text/plain", "text/html"}, order, "plain text must come first")
+	return result
+}
+
+func TestSendEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      Body
+		wantType  string
+		wantParts map[string]string
+	}{
+		{
+			name:      "HTML and text become multipart/alternative",
+			body:      Body{HTML: "<p>Temperature: 21°C</p>\n<p>Sunny</p>", Text: "Temperature: 21°C\nSunny"},
+			wantType:  "multipart/alternative",
+			wantParts: map[string]string{"text/plain": "Temperature: 21°C\r\nSunny", "text/html": "<p>Temperature: 21°C</p>\r\n<p>Sunny</p>"},
+		},
+		{
+			name:      "Text only",
+			body:      Body{Text: "Sunny"},
+			wantType:  "text/plain",
+			wantParts: map[string]string{"text/plain": "Sunny"},
+		},
+		{
+			name:      "HTML only",
+			body:      Body{HTML: "<p>Sunny</p>"},
+			wantType:  "text/html",
+			wantParts: map[string]string{"text/html": "<p>Sunny</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, sender := newTestClient()
+			err := c.SendEmail(context.Background(), "user@example.com", "Kyiv forecast", tt.body, Header{Name: "X-Test", Value: "1"})
+			require.NoError(t, err)
+
+			assert.NotRegexp(t, "[^\r]\n", string(sender.msg), "every line must end with CRLF")
+			msg, err := mail.ReadMessage(bytes.NewReader(sender.msg))
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", msg.Header.Get("To"))
+			assert.Equal(t, "Kyiv forecast", msg.Header.Get("Subject"))
+			assert.Equal(t, "1", msg.Header.Get("X-Test"))
+			assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+			mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, mediaType)
+
+			assert.Equal(t, tt.wantParts, parts(t, sender.msg))
+		})
+	}
+}
+
+func TestSendEmailHeaders(t *testing.T) {
+	sender := &recordingSender{}
+	cfg := &config.Config{
+		EmailClientFrom:     "no-reply@weather.example.com",
+		EmailClientFromName: "Погода",
+		EmailClientReplyTo:  "support@weather.example.com",
+		EmailClientHost:     "localhost",
+		EmailClientPort:     "25",
+	}
+	c := NewEmailClientWithSender(cfg, sender)
+	now := time.Date(2025, time.May, 17, 8, 0, 0, 0, time.UTC)
+	c.clock = clock.NewFake(now)
+
+	require.NoError(t, c.SendEmail(context.Background(), "user@example.com", "Київ forecast", Body{Text: "Sunny"}))
+	msg, err := mail.ReadMessage(bytes.NewReader(sender.msg))
+	require.NoError(t, err)
+
+	from, err := msg.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, []*mail.Address{{Name: "Погода", Address: "no-reply@weather.example.com"}}, from)
+	assert.Equal(t, "support@weather.example.com", msg.Header.Get("Reply-To"))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Київ forecast", subject)
+
+	date, err := msg.Header.Date()
+	require.NoError(t, err)
+	assert.True(t, now.Equal(date))
+	assert.Regexp(t, `^<[0-9a-f]{32}@weather\.example\.com>$`, msg.Header.Get("Message-ID"))
+}
+
+func TestSendUpdateEscapesHTMLPartOnly(t *testing.T) {
+	c, sender := newTestClient()
+	weather := &model.WeatherAPIResponse{}
+	weather.Current.TempC = 21
+	weather.Current.Condition.Text = "Sun & <clouds>"
+	links := UpdateLinks{Unsubscribe: "https://example.com/unsubscribe?token=a&b", OneClickUnsubscribe: "https://example.com/api/unsubscribe/a"}
+
+	err := SendUpdate(context.Background(), &model.Subscription{Email: "user@example.com", City: "Kyiv"}, weather, links, c)
+	require.NoError(t, err)
+
+	got := parts(t, sender.msg)
+	assert.Contains(t, got["text/html"], "Sun &amp; &lt;clouds&gt;")
+	assert.Contains(t, got["text/html"], `href="https://example.com/unsubscribe?token=a&amp;b"`)
+	assert.Contains(t, got["text/plain"], "Sun & <clouds>")
+	assert.Contains(t, got["text/plain"], "https://example.com/unsubscribe?token=a&b")
+	assert.Contains(t, string(sender.msg), "List-Unsubscribe: <https://example.com/api/unsubscribe/a>")
+}
+
+func TestLoadTemplates(t *testing.T) {
+	t.Cleanup(func() { emailTemplates = templates.Default() })
+
+	t.Run("A template using data that does not exist is rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "update.txt.tmpl"), []byte("{{.Town}}"), 0o644))
+
+		require.Error(t, LoadTemplates(dir))
+	})
+
+	t.Run("Valid templates are used for every email", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "confirm.txt.tmpl"), []byte("Confirm at {{.URL}}"), 0o644))
+		require.NoError(t, LoadTemplates(dir))
+
+		c, sender := newTestClient()
+		require.NoError(t, SendConfirmation(context.Background(), "user@example.com", "https://example.com/confirm", c))
+		assert.Equal(t, "Confirm at https://example.com/confirm", parts(t, sender.msg)["text/plain"])
+	})
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email in the form it is handed to the mail server.
+type Message struct {
+	From      mail.Address
+	ReplyTo   string
+	To        string
+	Subject   string
+	Date      time.Time
+	MessageID string
+	Headers   []Header
+	Body      Body
+
+	// boundary separates the parts of a multipart message; a random one is used when it is empty.
+	boundary string
+}
+
+// Bytes formats the message per RFC 5322 with CRLF line endings throughout. Header values cannot break
+// the header section: their line breaks are replaced by spaces, and the subject is RFC 2047 encoded when
+// it is not plain ASCII. A body with both parts becomes a multipart/alternative message with the
+// plain-text part first, so clients prefer the HTML one. Parts are UTF-8, quoted-printable encoded.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+
+	writeHeader("From", m.From.String())
+	if m.ReplyTo != "" {
+		writeHeader("Reply-To", headerValue(m.ReplyTo))
+	}
+	writeHeader("To", headerValue(m.To))
+	writeHeader("Subject", encodeSubject(m.Subject))
+	writeHeader("Date", m.Date.Format(time.RFC1123Z))
+	writeHeader("Message-ID", headerValue(m.MessageID))
+	for _, h := range m.Headers {
+		writeHeader(h.Name, headerValue(h.Value))
+	}
+	writeHeader("MIME-Version", "1.0")
+
+	if m.Body.HTML == "" || m.Body.Text == "" {
+		contentType, content := "text/html", m.Body.HTML
+		if m.Body.HTML == "" {
+			contentType, content = "text/plain", m.Body.Text
+		}
+		writeHeader("Content-Type", contentType+`; charset="UTF-8"`)
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, content); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	if m.boundary != "" {
+		if err := parts.SetBoundary(m.boundary); err != nil {
+			return nil, err
+		}
+	}
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", m.Body.Text},
+		{"text/html", m.Body.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="UTF-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// headerValue keeps a value on its header's line.
+func headerValue(value string) string {
+	return strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+}
+
+// encodeSubject returns the subject as RFC 2047 encoded-words when it is not plain ASCII, each on its
+// own folded line so that no line exceeds the recommended 78 characters.
+func encodeSubject(subject string) string {
+	encoded := mime.QEncoding.Encode("UTF-8", headerValue(subject))
+	return strings.ReplaceAll(encoded, "?= =?", "?=\r\n =?")
+}
+
+// writeQuotedPrintable encodes content, turning its line breaks, whether LF, CRLF or CR, into CRLF.
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// newMessageID returns a globally unique Message-ID in the domain of the sender's address.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">"
+}
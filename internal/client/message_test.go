@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bytes"
+	"flag"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestMessageBytes(t *testing.T) {
+	kyiv := time.FixedZone("EEST", 3*60*60)
+	base := func() Message {
+		return Message{
+			From:      mail.Address{Address: "no-reply@weather.example.com"},
+			To:        "user@example.com",
+			Subject:   "Kyiv forecast",
+			Date:      time.Date(2025, time.May, 17, 8, 0, 0, 0, kyiv),
+			MessageID: "<0123456789abcdef@weather.example.com>",
+			Body:      Body{Text: "Sunny"},
+			boundary:  "weather-boundary",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		golden string
+		modify func(m *Message)
+	}{
+		{
+			name:   "Plain text",
+			golden: "plain_text",
+			modify: func(m *Message) {},
+		},
+		{
+			name:   "Multipart with display name, Reply-To and list headers",
+			golden: "multipart",
+			modify: func(m *Message) {
+				m.From.Name = "Weather Updates"
+				m.ReplyTo = "support@weather.example.com"
+				m.Headers = UnsubscribeHeaders("https://weather.example.com/api/subscription/unsubscribe/abc")
+				m.Body = Body{
+					HTML: "<p>Weather for Kyiv:</p>\n<p>21.0°C</p>",
+					Text: "Weather for Kyiv:\n- temperature: 21.0°C",
+				}
+			},
+		},
+		{
+			name:   "Non-ASCII subject and display name are encoded",
+			golden: "encoded_subject",
+			modify: func(m *Message) {
+				m.From.Name = "Погода"
+				m.Subject = "Київ forecast"
+			},
+		},
+		{
+			name:   "Long encoded subject is folded",
+			golden: "folded_subject",
+			modify: func(m *Message) {
+				m.Subject = "Щоденний дайджест погоди: Київ, Львів, Харків, Одеса, Дніпро"
+			},
+		},
+		{
+			name:   "Mixed line endings become CRLF",
+			golden: "line_endings",
+			modify: func(m *Message) {
+				m.Body = Body{HTML: "<p>one</p>\r\n<p>two</p>\n<p>three</p>\r<p>four</p>", Text: "one\r\ntwo\nthree\rfour\n"}
+			},
+		},
+		{
+			name:   "Line breaks cannot inject headers",
+			golden: "header_injection",
+			modify: func(m *Message) {
+				m.Subject = "Kyiv forecast\r\nBcc: victim@example.com"
+				m.Headers = []Header{{Name: "List-Unsubscribe", Value: "<https://example.com>\nBcc: victim@example.com"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base()
+			tt.modify(&m)
+
+			got, err := m.Bytes()
+			require.NoError(t, err)
+
+			assert.NotRegexp(t, "[^\r]\n|\r[^\n]", string(got), "every line must end with CRLF")
+			parsed, err := mail.ReadMessage(bytes.NewReader(got))
+			require.NoError(t, err)
+			assert.Empty(t, parsed.Header.Get("Bcc"))
+
+			path := filepath.Join("testdata", tt.golden+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(path, got, 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestNewMessageID(t *testing.T) {
+	id := newMessageID("no-reply@weather.example.com")
+	assert.Regexp(t, `^<[0-9a-f]{32}@weather\.example\.com>$`, id)
+	assert.NotEqual(t, id, newMessageID("no-reply@weather.example.com"))
+
+	assert.Regexp(t, `@localhost>$`, newMessageID("apikey"))
+}
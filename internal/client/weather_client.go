@@ -12,6 +12,17 @@ type WeatherClient interface {
 	GetCurrentWeather(city string) (*model.WeatherAPIResponse, error)
 }
 
+// WeatherAPIError is returned when WeatherAPI.com answers with a status other than 200 OK.
+// It answers 400 for a city it does not know.
+type WeatherAPIError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *WeatherAPIError) Error() string {
+	return fmt.Sprintf("weather API returned status %d: %s", e.StatusCode, e.Status)
+}
+
 // weatherClient implements WeatherClient interface
 type weatherClient struct {
 	apiKey     string
@@ -56,7 +67,7 @@ func (c *weatherClient) GetCurrentWeather(city string) (*model.WeatherAPIRespons
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("weather API returned status %d: %s", resp.StatusCode, resp.Status)
+		return nil, &WeatherAPIError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	// Decode response
@@ -2,16 +2,93 @@ package config
 
 import (
 	"fmt"
+	"net/mail"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
+// Policies for delivery slots missed while the scheduler was not running.
+const (
+	MissedSlotsCatchUp = "catch-up"
+	MissedSlotsSkip    = "skip"
+)
+
 type Config struct {
 	Env            string `env:"APP_ENV"   envDefault:"local"`
 	AppPort        string `env:"APP_PORT" envDefault:":8080"`
 	BaseURL        string `env:"APP_BASE_URL"`
+	TokenSecret    string `env:"TOKEN_SECRET"`
 	DailyStartHour int    `env:"DAILY_START_HOUR" envDefault:"8"`
 
+	// On SIGINT or SIGTERM in-flight deliveries get SHUTDOWN_DELIVERY_TIMEOUT to finish; in-flight HTTP
+	// requests and closing each resource then get SHUTDOWN_TIMEOUT
+	ShutdownDeliveryTimeout time.Duration `env:"SHUTDOWN_DELIVERY_TIMEOUT" envDefault:"20s"`
+	ShutdownTimeout         time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"5s"`
+
+	// The dispatcher polls for due subscriptions; slots missed while no instance was running are
+	// either sent once on the next poll (catch-up) or dropped if later than the grace period (skip)
+	SchedulerPollInterval    time.Duration `env:"SCHEDULER_POLL_INTERVAL" envDefault:"15s"`
+	SchedulerBatchSize       int           `env:"SCHEDULER_BATCH_SIZE" envDefault:"100"`
+	SchedulerMissedSlots     string        `env:"SCHEDULER_MISSED_SLOTS" envDefault:"catch-up"`
+	SchedulerMissedSlotGrace time.Duration `env:"SCHEDULER_MISSED_SLOT_GRACE" envDefault:"10m"`
+	// Weather is fetched once per location of a claimed batch, then emails are sent by SCHEDULER_WORKERS workers
+	SchedulerWorkers int `env:"SCHEDULER_WORKERS" envDefault:"8"`
+	// Replicas lease the subscriptions they claim; a lease not renewed within SCHEDULER_LEASE_TTL is
+	// taken over by another instance. The instance ID defaults to the hostname plus a random suffix.
+	SchedulerInstanceID string        `env:"SCHEDULER_INSTANCE_ID"`
+	SchedulerLeaseTTL   time.Duration `env:"SCHEDULER_LEASE_TTL" envDefault:"1m"`
+	// With SCHEDULER_LISTEN the dispatcher also polls as soon as the database announces a change it acts on;
+	// the regular polls still catch anything a lost notification would have announced
+	SchedulerListen bool `env:"SCHEDULER_LISTEN" envDefault:"true"`
+	// Each subscriber is sent to at a fixed offset within SCHEDULER_JITTER after the slot, so a slot's emails
+	// are spread instead of sent at once; SCHEDULER_SEND_RATE caps emails per second (0 means no cap). The cap is
+	// per instance, so with several replicas set it to the provider's limit divided by the number of replicas
+	SchedulerJitter   time.Duration `env:"SCHEDULER_JITTER" envDefault:"0s"`
+	SchedulerSendRate float64       `env:"SCHEDULER_SEND_RATE" envDefault:"0"`
+
+	// Deliveries failing transiently are retried after DELIVERY_RETRY_BACKOFF, doubling up to
+	// DELIVERY_RETRY_MAX_BACKOFF, until DELIVERY_MAX_ATTEMPTS attempts have been made
+	DeliveryMaxAttempts     int           `env:"DELIVERY_MAX_ATTEMPTS" envDefault:"5"`
+	DeliveryRetryBackoff    time.Duration `env:"DELIVERY_RETRY_BACKOFF" envDefault:"1m"`
+	DeliveryRetryMaxBackoff time.Duration `env:"DELIVERY_RETRY_MAX_BACKOFF" envDefault:"1h"`
+
+	ConfirmTokenTTL        time.Duration `env:"CONFIRM_TOKEN_TTL" envDefault:"24h"`
+	PendingRetention       time.Duration `env:"PENDING_RETENTION" envDefault:"168h"`
+	PendingCleanupInterval time.Duration `env:"PENDING_CLEANUP_INTERVAL" envDefault:"1h"`
+
+	MaxPauseDays  int   `env:"MAX_PAUSE_DAYS" envDefault:"30"`
+	PauseLinkDays []int `env:"PAUSE_LINK_DAYS" envDefault:"7,14"`
+
+	MaxSubscriptionsPerEmail int           `env:"MAX_SUBSCRIPTIONS_PER_EMAIL" envDefault:"10"`
+	ConfirmResendCooldown    time.Duration `env:"CONFIRM_RESEND_COOLDOWN" envDefault:"5m"`
+	SubscribeRateLimit       int           `env:"SUBSCRIBE_RATE_LIMIT" envDefault:"5"`
+	SubscribeRateWindow      time.Duration `env:"SUBSCRIBE_RATE_WINDOW" envDefault:"1m"`
+	TrustedProxies           []string      `env:"TRUSTED_PROXIES"`
+
+	PrivacyLinkTTL         time.Duration `env:"PRIVACY_LINK_TTL" envDefault:"1h"`
+	PrivacyRequestCooldown time.Duration `env:"PRIVACY_REQUEST_COOLDOWN" envDefault:"5m"`
+
+	// With DRY_RUN every email is captured for review through the admin API instead of being sent; single
+	// admin requests can ask for the same with ?dry_run=true
+	DryRun bool `env:"DRY_RUN" envDefault:"false"`
+
+	// Templates in EMAIL_TEMPLATE_DIR replace the embedded email templates of the same name
+	EmailTemplateDir string `env:"EMAIL_TEMPLATE_DIR"`
+
+	// The admin API is only served when both credentials are set
+	AdminUser     string `env:"ADMIN_USER"`
+	AdminPassword string `env:"ADMIN_PASSWORD"`
+
+	// The bounce and complaint webhook is only served when WEBHOOK_SECRET is set
+	WebhookSecret               string        `env:"WEBHOOK_SECRET"`
+	SuppressionFailureThreshold int           `env:"SUPPRESSION_FAILURE_THRESHOLD" envDefault:"3"`
+	SuppressionFailureWindow    time.Duration `env:"SUPPRESSION_FAILURE_WINDOW" envDefault:"168h"`
+
+	ImportMaxRows              int           `env:"IMPORT_MAX_ROWS" envDefault:"10000"`
+	ImportConfirmBatchSize     int           `env:"IMPORT_CONFIRM_BATCH_SIZE" envDefault:"50"`
+	ImportConfirmBatchInterval time.Duration `env:"IMPORT_CONFIRM_BATCH_INTERVAL" envDefault:"10s"`
+
 	PostgresContainerHost string `env:"POSTGRES_CONTAINER_HOST"`
 	PostgresContainerPort int    `env:"POSTGRES_CONTAINER_PORT"`
 	PostgresUser          string `env:"POSTGRES_USER"`
@@ -21,6 +98,8 @@ type Config struct {
 	WeatherApiKey string `env:"WEATHER_API_KEY"`
 
 	EmailClientFrom     string `env:"SMTP_FROM"`
+	EmailClientFromName string `env:"SMTP_FROM_NAME"`
+	EmailClientReplyTo  string `env:"SMTP_REPLY_TO"`
 	EmailClientPassword string `env:"SMTP_PASSWORD"`
 	EmailClientHost     string `env:"SMTP_HOST"`
 	EmailClientPort     string `env:"SMTP_PORT"`
@@ -50,9 +129,17 @@ func (cfg *Config) Validate() error {
 	if cfg.BaseURL == "" {
 		return fmt.Errorf("APP_BASE_URL is required")
 	}
+	if cfg.TokenSecret == "" {
+		return fmt.Errorf("TOKEN_SECRET is required")
+	}
 	if cfg.EmailClientFrom == "" {
 		return fmt.Errorf("SMTP_FROM is required")
 	}
+	if cfg.EmailClientReplyTo != "" {
+		if _, err := mail.ParseAddressList(cfg.EmailClientReplyTo); err != nil {
+			return fmt.Errorf("SMTP_REPLY_TO must be a list of email addresses: %w", err)
+		}
+	}
 	if cfg.EmailClientPassword == "" {
 		return fmt.Errorf("SMTP_PASSWORD is required")
 	}
@@ -74,9 +161,106 @@ func (cfg *Config) Validate() error {
 	if cfg.PostgresDB == "" {
 		return fmt.Errorf("POSTGRES_DB is required")
 	}
+	if cfg.ConfirmTokenTTL <= 0 {
+		return fmt.Errorf("CONFIRM_TOKEN_TTL must be positive")
+	}
+	if cfg.PendingRetention < cfg.ConfirmTokenTTL {
+		return fmt.Errorf("PENDING_RETENTION must not be shorter than CONFIRM_TOKEN_TTL")
+	}
+	if cfg.PendingCleanupInterval <= 0 {
+		return fmt.Errorf("PENDING_CLEANUP_INTERVAL must be positive")
+	}
+	if cfg.MaxPauseDays <= 0 {
+		return fmt.Errorf("MAX_PAUSE_DAYS must be positive")
+	}
+	if cfg.MaxSubscriptionsPerEmail <= 0 {
+		return fmt.Errorf("MAX_SUBSCRIPTIONS_PER_EMAIL must be positive")
+	}
+	if cfg.ConfirmResendCooldown < 0 {
+		return fmt.Errorf("CONFIRM_RESEND_COOLDOWN must not be negative")
+	}
+	if cfg.SubscribeRateLimit > 0 && cfg.SubscribeRateWindow <= 0 {
+		return fmt.Errorf("SUBSCRIBE_RATE_WINDOW must be positive when SUBSCRIBE_RATE_LIMIT is set")
+	}
+	if (cfg.AdminUser == "") != (cfg.AdminPassword == "") {
+		return fmt.Errorf("ADMIN_USER and ADMIN_PASSWORD must be set together")
+	}
+	if cfg.SuppressionFailureThreshold <= 0 {
+		return fmt.Errorf("SUPPRESSION_FAILURE_THRESHOLD must be positive")
+	}
+	if cfg.SuppressionFailureWindow <= 0 {
+		return fmt.Errorf("SUPPRESSION_FAILURE_WINDOW must be positive")
+	}
+	if cfg.ImportMaxRows <= 0 {
+		return fmt.Errorf("IMPORT_MAX_ROWS must be positive")
+	}
+	if cfg.ImportConfirmBatchSize <= 0 {
+		return fmt.Errorf("IMPORT_CONFIRM_BATCH_SIZE must be positive")
+	}
+	if cfg.ImportConfirmBatchInterval < 0 {
+		return fmt.Errorf("IMPORT_CONFIRM_BATCH_INTERVAL must not be negative")
+	}
+	if cfg.PrivacyLinkTTL <= 0 {
+		return fmt.Errorf("PRIVACY_LINK_TTL must be positive")
+	}
+	if cfg.PrivacyRequestCooldown < 0 {
+		return fmt.Errorf("PRIVACY_REQUEST_COOLDOWN must not be negative")
+	}
+	if cfg.DailyStartHour < 0 || cfg.DailyStartHour > 23 {
+		return fmt.Errorf("DAILY_START_HOUR must be between 0 and 23")
+	}
+	if cfg.SchedulerPollInterval <= 0 {
+		return fmt.Errorf("SCHEDULER_POLL_INTERVAL must be positive")
+	}
+	if cfg.SchedulerBatchSize <= 0 {
+		return fmt.Errorf("SCHEDULER_BATCH_SIZE must be positive")
+	}
+	if cfg.SchedulerMissedSlots != MissedSlotsCatchUp && cfg.SchedulerMissedSlots != MissedSlotsSkip {
+		return fmt.Errorf("SCHEDULER_MISSED_SLOTS must be %q or %q", MissedSlotsCatchUp, MissedSlotsSkip)
+	}
+	if cfg.SchedulerMissedSlotGrace < 0 {
+		return fmt.Errorf("SCHEDULER_MISSED_SLOT_GRACE must not be negative")
+	}
+	if cfg.SchedulerWorkers <= 0 {
+		return fmt.Errorf("SCHEDULER_WORKERS must be positive")
+	}
+	if cfg.SchedulerJitter < 0 || cfg.SchedulerJitter >= time.Hour {
+		return fmt.Errorf("SCHEDULER_JITTER must be at least 0 and shorter than an hour")
+	}
+	if cfg.SchedulerSendRate < 0 {
+		return fmt.Errorf("SCHEDULER_SEND_RATE must not be negative")
+	}
+	if cfg.SchedulerLeaseTTL <= 0 {
+		return fmt.Errorf("SCHEDULER_LEASE_TTL must be positive")
+	}
+	if cfg.ShutdownDeliveryTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DELIVERY_TIMEOUT must be positive")
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if cfg.DeliveryMaxAttempts <= 0 {
+		return fmt.Errorf("DELIVERY_MAX_ATTEMPTS must be positive")
+	}
+	if cfg.DeliveryRetryBackoff <= 0 {
+		return fmt.Errorf("DELIVERY_RETRY_BACKOFF must be positive")
+	}
+	if cfg.DeliveryRetryMaxBackoff < cfg.DeliveryRetryBackoff {
+		return fmt.Errorf("DELIVERY_RETRY_MAX_BACKOFF must not be shorter than DELIVERY_RETRY_BACKOFF")
+	}
+	for _, days := range cfg.PauseLinkDays {
+		if days <= 0 || days > cfg.MaxPauseDays {
+			return fmt.Errorf("PAUSE_LINK_DAYS must be between 1 and MAX_PAUSE_DAYS")
+		}
+	}
 	return nil
 }
 
+// AdminEnabled reports whether admin credentials are configured.
+func (cfg *Config) AdminEnabled() bool {
+	return cfg.AdminUser != "" && cfg.AdminPassword != ""
+}
+
 func (cfg *Config) GetDSN() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
@@ -2,11 +2,41 @@ package config
 
 import "fmt"
 
-const ConfirmSubject = "Confirm your subscription"
+const (
+	ConfirmSubject       = "Confirm your subscription"
+	PrivacyExportSubject = "Your weather subscription data"
+	PrivacyEraseSubject  = "Confirm deletion of your weather subscription data"
+)
 
-func BuildConfirmBody(baseURL, token string) string {
-	return fmt.Sprintf(
-		`<p>Click <a href="%s/api/subscription/confirm/%s">here</a> to confirm your subscription.</p>`,
-		baseURL, token,
-	)
+func BuildConfirmURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/api/subscription/confirm/%s", baseURL, token)
+}
+
+// BuildUnsubscribeURL points at the confirmation page, so mail scanners following links cannot unsubscribe.
+func BuildUnsubscribeURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/static/unsubscribe.html?token=%s", baseURL, token)
+}
+
+// BuildOneClickUnsubscribeURL is the RFC 8058 endpoint that mail clients post to from the List-Unsubscribe header.
+func BuildOneClickUnsubscribeURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/api/subscription/unsubscribe/%s", baseURL, token)
+}
+
+// BuildPauseURL points at the confirmation page, so mail scanners following links cannot pause updates.
+func BuildPauseURL(baseURL, token string, days int) string {
+	return fmt.Sprintf("%s/static/pause.html?token=%s&days=%d", baseURL, token, days)
+}
+
+// BuildResumeURL points at the confirmation page, like BuildPauseURL.
+func BuildResumeURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/static/resume.html?token=%s", baseURL, token)
+}
+
+func BuildPrivacyExportURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/api/privacy/export/%s", baseURL, token)
+}
+
+// BuildPrivacyEraseURL points at the confirmation page, so mail scanners following links cannot erase data.
+func BuildPrivacyEraseURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/static/erase.html?token=%s", baseURL, token)
 }
@@ -0,0 +1,880 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/middleware"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/services/bulk_service"
+	"Weather-API-Application/internal/services/capture_service"
+	"Weather-API-Application/internal/services/delivery_service"
+	"Weather-API-Application/internal/services/scheduler_service"
+	"Weather-API-Application/internal/services/subscription_service"
+	"Weather-API-Application/internal/services/suppression_service"
+	"Weather-API-Application/internal/utils/response"
+	"Weather-API-Application/internal/utils/validate"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+	maxImportBytes       = 32 << 20
+)
+
+type AdminHandler struct {
+	config              *config.Config
+	subscriptionService *subscription_service.SubscriptionService
+	schedulerService    *scheduler_service.SchedulerService
+	bulkService         *bulk_service.BulkService
+	suppressionService  *suppression_service.SuppressionService
+	deliveryService     *delivery_service.DeliveryService
+	captureService      *capture_service.CaptureService
+}
+
+func NewAdminHandler(cfg *config.Config, subSvc *subscription_service.SubscriptionService, schedulerSvc *scheduler_service.SchedulerService, bulkSvc *bulk_service.BulkService, suppressionSvc *suppression_service.SuppressionService, deliverySvc *delivery_service.DeliveryService, captureSvc *capture_service.CaptureService) *AdminHandler {
+	return &AdminHandler{
+		config:              cfg,
+		subscriptionService: subSvc,
+		schedulerService:    schedulerSvc,
+		bulkService:         bulkSvc,
+		suppressionService:  suppressionSvc,
+		deliveryService:     deliverySvc,
+		captureService:      captureSvc,
+	}
+}
+
+// RegisterRoutes registers admin endpoints behind HTTP Basic authentication.
+func (h *AdminHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/api/admin", middleware.AdminAuth(h.config.AdminUser, h.config.AdminPassword))
+	{
+		admin.GET("/subscriptions", h.ListSubscriptions)
+		admin.POST("/subscriptions/import", h.ImportSubscriptions)
+		admin.GET("/subscriptions/export", h.ExportSubscriptions)
+		admin.GET("/subscriptions/:id", h.GetSubscription)
+		admin.DELETE("/subscriptions/:id", h.DeleteSubscription)
+		admin.POST("/subscriptions/:id/confirm", h.ForceConfirm)
+		admin.POST("/subscriptions/:id/resend-confirmation", h.ResendConfirmation)
+		admin.POST("/subscriptions/:id/send-now", h.SendNow)
+		admin.GET("/suppressions", h.ListSuppressions)
+		admin.DELETE("/suppressions/:email", h.LiftSuppression)
+		admin.GET("/deliveries", h.ListDeliveries)
+		admin.GET("/subscribers/:email/deliveries", h.RecentDeliveries)
+		admin.GET("/schedules", h.ListSchedules)
+		admin.GET("/scheduler", h.SchedulerStatus)
+		admin.POST("/scheduler/pause", h.PauseScheduler)
+		admin.POST("/scheduler/resume", h.ResumeScheduler)
+		admin.GET("/dead-letters", h.ListDeadLetters)
+		admin.POST("/dead-letters/:id/replay", h.ReplayDeadLetter)
+		admin.GET("/captured-emails", h.ListCapturedEmails)
+		admin.DELETE("/captured-emails", h.ClearCapturedEmails)
+	}
+}
+
+// ListSubscriptions godoc
+// @Summary      List subscriptions
+// @Description  Returns a page of subscriptions, newest first, filtered by the given criteria.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email         query     string  false  "Exact email, case-insensitive"
+// @Param        city          query     string  false  "Exact city, case-insensitive"
+// @Param        frequency     query     string  false  "hourly or daily"
+// @Param        confirmed     query     bool    false  "Confirmed state"
+// @Param        created_from  query     string  false  "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param        created_to    query     string  false  "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param        limit         query     int     false  "Page size (default 50, max 200)"
+// @Param        offset        query     int     false  "Number of matches to skip"
+// @Success      200  {object}  model.AdminSubscriptionPage  "Subscriptions"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid filter"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/subscriptions [get]
+func (h *AdminHandler) ListSubscriptions(ctx *gin.Context) {
+	filter, err := parseSubscriptionFilter(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	subs, total, err := h.subscriptionService.List(ctx.Request.Context(), filter)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+
+	page := model.AdminSubscriptionPage{
+		Items:  make([]model.AdminSubscription, 0, len(subs)),
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	for _, sub := range subs {
+		page.Items = append(page.Items, model.NewAdminSubscription(sub))
+	}
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetSubscription godoc
+// @Summary      Get a subscription
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id   path      string  true  "Subscription id (integer)"
+// @Success      200  {object}  model.AdminSubscription  "Subscription"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid subscription id"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Subscription not found"
+// @Router       /admin/subscriptions/{id} [get]
+func (h *AdminHandler) GetSubscription(ctx *gin.Context) {
+	id, ok := subscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	sub, err := h.subscriptionService.Get(ctx.Request.Context(), id)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.NewAdminSubscription(sub))
+}
+
+// DeleteSubscription godoc
+// @Summary      Delete a subscription
+// @Description  Removes the subscription and stops its deliveries.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id   path      string  true  "Subscription id (integer)"
+// @Success      200  {string}  string  "Subscription deleted"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid subscription id"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Subscription not found"
+// @Router       /admin/subscriptions/{id} [delete]
+func (h *AdminHandler) DeleteSubscription(ctx *gin.Context) {
+	id, ok := subscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.subscriptionService.Delete(ctx.Request.Context(), id); err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Subscription deleted."})
+}
+
+// ForceConfirm godoc
+// @Summary      Force-confirm a subscription
+// @Description  Confirms a pending subscription without its confirmation link and schedules its deliveries.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id   path      string  true  "Subscription id (integer)"
+// @Success      200  {object}  model.AdminSubscription  "Subscription confirmed"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid subscription id"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Subscription not found"
+// @Failure      409  {object}  response.ErrorResponse  "Already confirmed"
+// @Router       /admin/subscriptions/{id}/confirm [post]
+func (h *AdminHandler) ForceConfirm(ctx *gin.Context) {
+	id, ok := subscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	sub, err := h.subscriptionService.ForceConfirm(ctx.Request.Context(), id)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.NewAdminSubscription(sub))
+}
+
+// ResendConfirmation godoc
+// @Summary      Resend the confirmation email
+// @Description  Emails a new confirmation link for a pending subscription, ignoring the resend cooldown.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id       path      string  true   "Subscription id (integer)"
+// @Param        dry_run  query     bool    false  "Capture the email instead of sending it; the current link stays valid"
+// @Success      200  {string}  string  "Confirmation email sent or captured"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid subscription id or dry_run"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Subscription not found"
+// @Failure      409  {object}  response.ErrorResponse  "Already confirmed"
+// @Router       /admin/subscriptions/{id}/resend-confirmation [post]
+func (h *AdminHandler) ResendConfirmation(ctx *gin.Context) {
+	id, ok := subscriptionID(ctx)
+	if !ok {
+		return
+	}
+	reqCtx, err := dryRunContext(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	if _, err := h.subscriptionService.ResendConfirmation(reqCtx, id); err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	if h.captureService.DryRun(reqCtx) {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Confirmation email captured (dry run)."})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Confirmation email sent."})
+}
+
+// SendNow godoc
+// @Summary      Send an update now
+// @Description  Sends a weather update for a confirmed subscription immediately, ignoring pauses and quiet hours.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id       path      string  true   "Subscription id (integer)"
+// @Param        dry_run  query     bool    false  "Capture the update instead of sending it"
+// @Success      200  {string}  string  "Update sent or captured"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid subscription id or dry_run"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Subscription not found"
+// @Failure      409  {object}  response.ErrorResponse  "Subscription not confirmed"
+// @Failure      502  {object}  response.ErrorResponse  "Update could not be sent"
+// @Router       /admin/subscriptions/{id}/send-now [post]
+func (h *AdminHandler) SendNow(ctx *gin.Context) {
+	id, ok := subscriptionID(ctx)
+	if !ok {
+		return
+	}
+	reqCtx, err := dryRunContext(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	sub, err := h.subscriptionService.Get(reqCtx, id)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+
+	if err := h.schedulerService.SendNow(reqCtx, sub); err != nil {
+		if errors.Is(err, scheduler_service.ErrNotConfirmed) {
+			response.WriteErrorJSON(ctx, http.StatusConflict, err, "Subscription is not confirmed")
+			return
+		}
+		response.WriteErrorJSON(ctx, http.StatusBadGateway, err, "Update could not be sent")
+		return
+	}
+	if h.captureService.DryRun(reqCtx) {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Update captured (dry run)."})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Update sent."})
+}
+
+// ListSuppressions godoc
+// @Summary      List suppressed addresses
+// @Description  Returns a page of addresses that receive no email because they bounced or complained, most recently suppressed first.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        limit   query     int  false  "Page size (default 50, max 200)"
+// @Param        offset  query     int  false  "Number of entries to skip"
+// @Success      200  {object}  model.SuppressionPage  "Suppressed addresses"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid paging"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/suppressions [get]
+func (h *AdminHandler) ListSuppressions(ctx *gin.Context) {
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	items, total, err := h.suppressionService.List(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.Suppression{}
+	}
+	ctx.JSON(http.StatusOK, model.SuppressionPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// LiftSuppression godoc
+// @Summary      Lift a suppression
+// @Description  Allows email to be sent to the address again and resets its failure count.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email  path      string  true  "Suppressed email"
+// @Success      200  {string}  string  "Suppression lifted"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Address not suppressed"
+// @Router       /admin/suppressions/{email} [delete]
+func (h *AdminHandler) LiftSuppression(ctx *gin.Context) {
+	if err := h.suppressionService.Lift(ctx.Request.Context(), ctx.Param("email")); err != nil {
+		if errors.Is(err, suppression_service.ErrNotSuppressed) {
+			response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Address not suppressed")
+			return
+		}
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Suppression lifted."})
+}
+
+// ListDeliveries godoc
+// @Summary      List deliveries
+// @Description  Returns a page of the delivery log, most recent first. A digest appears once per city it covered.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email            query     string  false  "Exact email, case-insensitive"
+// @Param        subscription_id  query     string  false  "Subscription id (integer)"
+// @Param        status           query     string  false  "queued, sent, captured, retrying, failed, suppressed or skipped"
+// @Param        limit            query     int     false  "Page size (default 50, max 200)"
+// @Param        offset           query     int     false  "Number of entries to skip"
+// @Success      200  {object}  model.DeliveryPage  "Deliveries"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid filter"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/deliveries [get]
+func (h *AdminHandler) ListDeliveries(ctx *gin.Context) {
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	subId, err := parseSubscriptionIDFilter(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	filter := model.DeliveryFilter{
+		SubscriptionID: subId,
+		Email:          strings.TrimSpace(ctx.Query("email")),
+		Limit:          limit,
+		Offset:         offset,
+	}
+	if v := ctx.Query("status"); v != "" {
+		switch v {
+		case model.DeliveryQueued, model.DeliverySent, model.DeliveryCaptured, model.DeliveryRetrying, model.DeliveryFailed, model.DeliverySuppressed, model.DeliverySkipped:
+			filter.Status = v
+		default:
+			err := fmt.Errorf("status must be 'queued', 'sent', 'captured', 'retrying', 'failed', 'suppressed' or 'skipped'")
+			response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+			return
+		}
+	}
+
+	items, total, err := h.deliveryService.List(ctx.Request.Context(), filter)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.Delivery{}
+	}
+	ctx.JSON(http.StatusOK, model.DeliveryPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// RecentDeliveries godoc
+// @Summary      Recent deliveries to a subscriber
+// @Description  Returns the latest deliveries to an email across all of its subscriptions, most recent first.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email  path      string  true   "Subscriber email"
+// @Param        limit  query     int     false  "Number of deliveries (default 50, max 200)"
+// @Success      200  {array}   model.Delivery  "Deliveries"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid limit"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/subscribers/{email}/deliveries [get]
+func (h *AdminHandler) RecentDeliveries(ctx *gin.Context) {
+	limit, _, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	items, err := h.deliveryService.Recent(ctx.Request.Context(), ctx.Param("email"), limit)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.Delivery{}
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+// ListSchedules godoc
+// @Summary      List delivery schedules
+// @Description  Returns a page of the schedules of confirmed subscriptions in the order they fall due, with each one's latest delivery and the failures since its last successful one.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email            query     string  false  "Exact email, case-insensitive"
+// @Param        subscription_id  query     string  false  "Subscription id (integer)"
+// @Param        limit            query     int     false  "Page size (default 50, max 200)"
+// @Param        offset           query     int     false  "Number of entries to skip"
+// @Success      200  {object}  model.SchedulePage  "Schedules"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid filter"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/schedules [get]
+func (h *AdminHandler) ListSchedules(ctx *gin.Context) {
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	subId, err := parseSubscriptionIDFilter(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	filter := model.ScheduleFilter{
+		SubscriptionID: subId,
+		Email:          strings.TrimSpace(ctx.Query("email")),
+		Limit:          limit,
+		Offset:         offset,
+	}
+
+	items, total, err := h.schedulerService.Schedules(ctx.Request.Context(), filter)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.Schedule{}
+	}
+	ctx.JSON(http.StatusOK, model.SchedulePage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// SchedulerStatus godoc
+// @Summary      Scheduler status
+// @Description  Reports whether the scheduler is paused. The state is shared by all instances; the answering one is named.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200  {object}  model.SchedulerStatus  "Scheduler status"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/scheduler [get]
+func (h *AdminHandler) SchedulerStatus(ctx *gin.Context) {
+	status, err := h.schedulerService.Status(ctx.Request.Context())
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// PauseScheduler godoc
+// @Summary      Pause the scheduler
+// @Description  Stops every instance from sending scheduled updates and retries from its next poll on. Batches in flight are finished and on-demand sends still work. Slots that fall due while paused are handled as missed slots on resume.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request  body      model.PauseSchedulerRequest  false  "Why the scheduler is paused"
+// @Success      200  {object}  model.SchedulerStatus  "Scheduler paused"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid request body"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/scheduler/pause [post]
+func (h *AdminHandler) PauseScheduler(ctx *gin.Context) {
+	// The body is optional
+	var req model.PauseSchedulerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	status, err := h.schedulerService.Pause(ctx.Request.Context(), strings.TrimSpace(req.Reason))
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// ResumeScheduler godoc
+// @Summary      Resume the scheduler
+// @Description  Lets every instance send scheduled updates and retries again from its next poll on.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200  {object}  model.SchedulerStatus  "Scheduler resumed"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/scheduler/resume [post]
+func (h *AdminHandler) ResumeScheduler(ctx *gin.Context) {
+	status, err := h.schedulerService.Resume(ctx.Request.Context())
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// ListDeadLetters godoc
+// @Summary      List dead letters
+// @Description  Returns a page of deliveries that failed for good, either permanently or after running out of retries, most recent first.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        limit   query     int  false  "Page size (default 50, max 200)"
+// @Param        offset  query     int  false  "Number of entries to skip"
+// @Success      200  {object}  model.DeliveryPage  "Dead letters"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid paging"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/dead-letters [get]
+func (h *AdminHandler) ListDeadLetters(ctx *gin.Context) {
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	items, total, err := h.deliveryService.DeadLetters(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.Delivery{}
+	}
+	ctx.JSON(http.StatusOK, model.DeliveryPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// ReplayDeadLetter godoc
+// @Summary      Replay a dead letter
+// @Description  Moves a failed delivery, with the rest of its digest, back to the retry queue. It is sent on the next scheduler poll.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id   path      int  true  "Delivery id"
+// @Success      202  {string}  string  "Delivery queued for replay"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid delivery id"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  response.ErrorResponse  "Dead letter not found"
+// @Router       /admin/dead-letters/{id}/replay [post]
+func (h *AdminHandler) ReplayDeadLetter(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		err := fmt.Errorf("delivery id must be a positive integer")
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	if err := h.deliveryService.Replay(ctx.Request.Context(), id); err != nil {
+		if errors.Is(err, delivery_service.ErrDeadLetterNotFound) {
+			response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Dead letter not found")
+			return
+		}
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Delivery queued for replay."})
+}
+
+// ImportSubscriptions godoc
+// @Summary      Import subscriptions
+// @Description  Imports subscribers from a CSV file (header with email, city, frequency and optional digest) or NDJSON. Every row is validated; invalid and already existing rows are reported by line and skipped. Rows are either stored confirmed or sent confirmation emails in throttled batches.
+// @Tags         admin
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Security     BasicAuth
+// @Param        format  query     string  false  "csv or ndjson; defaults to the Content-Type"
+// @Param        mode    query     string  true   "confirmed or send-confirmation"
+// @Success      200  {object}  model.ImportReport  "Import report"
+// @Failure      400  {object}  response.ErrorResponse  "Unreadable file or invalid parameters"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Failure      413  {object}  response.ErrorResponse  "File too large"
+// @Router       /admin/subscriptions/import [post]
+func (h *AdminHandler) ImportSubscriptions(ctx *gin.Context) {
+	format := ctx.Query("format")
+	if format == "" {
+		format = formatFromContentType(ctx.ContentType())
+	}
+	if !bulk_service.IsSupportedFormat(format) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("unsupported import format: %q", format),
+			"Format must be 'csv' or 'ndjson'")
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	report, err := h.bulkService.Import(ctx.Request.Context(), body, format, ctx.Query("mode"))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			response.WriteErrorJSON(ctx, http.StatusRequestEntityTooLarge, err,
+				fmt.Sprintf("File must not exceed %d MB", maxImportBytes>>20))
+		case errors.Is(err, bulk_service.ErrUnsupportedMode):
+			response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Mode must be 'confirmed' or 'send-confirmation'")
+		default:
+			// Anything else concerns the file as a whole, e.g. a missing header column or too many rows
+			response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ExportSubscriptions godoc
+// @Summary      Export subscriptions
+// @Description  Streams all subscriptions as CSV or NDJSON. The CSV can be imported again.
+// @Tags         admin
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     BasicAuth
+// @Param        format  query     string  false  "csv (default) or ndjson"
+// @Success      200  {file}    file  "Subscriptions"
+// @Failure      400  {object}  response.ErrorResponse  "Unsupported format"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/subscriptions/export [get]
+func (h *AdminHandler) ExportSubscriptions(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", bulk_service.FormatCSV)
+	if !bulk_service.IsSupportedFormat(format) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("unsupported export format: %q", format),
+			"Format must be 'csv' or 'ndjson'")
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == bulk_service.FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="subscriptions.%s"`, format))
+	ctx.Status(http.StatusOK)
+
+	// The status is already sent once streaming starts, so a failure can only be logged and cut the body short
+	if err := h.bulkService.Export(ctx.Request.Context(), ctx.Writer, format); err != nil {
+		ctx.Error(err)
+	}
+}
+
+// formatFromContentType maps an upload's Content-Type to an import format.
+func formatFromContentType(contentType string) string {
+	switch contentType {
+	case "text/csv":
+		return bulk_service.FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return bulk_service.FormatNDJSON
+	}
+	return ""
+}
+
+// ListCapturedEmails godoc
+// @Summary      List captured emails
+// @Description  Returns a page of the emails dry runs kept from being sent, most recent first, with their rendered subject, body and headers.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Param        email   query     string  false  "Exact recipient, case-insensitive"
+// @Param        limit   query     int     false  "Page size (default 50, max 200)"
+// @Param        offset  query     int     false  "Number of entries to skip"
+// @Success      200  {object}  model.CapturedEmailPage  "Captured emails"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid filter"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/captured-emails [get]
+func (h *AdminHandler) ListCapturedEmails(ctx *gin.Context) {
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	filter := model.CapturedEmailFilter{Email: strings.TrimSpace(ctx.Query("email")), Limit: limit, Offset: offset}
+
+	items, total, err := h.captureService.List(ctx.Request.Context(), filter)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	if items == nil {
+		items = []*model.CapturedEmail{}
+	}
+	ctx.JSON(http.StatusOK, model.CapturedEmailPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// ClearCapturedEmails godoc
+// @Summary      Clear captured emails
+// @Description  Deletes every email captured by dry runs.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200  {string}  string  "Captured emails cleared"
+// @Failure      401  {object}  response.ErrorResponse  "Unauthorized"
+// @Router       /admin/captured-emails [delete]
+func (h *AdminHandler) ClearCapturedEmails(ctx *gin.Context) {
+	n, err := h.captureService.Clear(ctx.Request.Context())
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Captured emails cleared.", "deleted": n})
+}
+
+// subscriptionID returns the subscription id of the path. Ids are integers; anything else is answered
+// with 400 here, so it never reaches the database.
+func subscriptionID(ctx *gin.Context) (string, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		err := fmt.Errorf("subscription id must be a positive integer")
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return "", false
+	}
+	return strconv.FormatInt(id, 10), true
+}
+
+// parseSubscriptionIDFilter reads the optional subscription_id query filter, which must be an integer like
+// the ids it is compared against.
+func parseSubscriptionIDFilter(ctx *gin.Context) (string, error) {
+	v := strings.TrimSpace(ctx.Query("subscription_id"))
+	if v == "" {
+		return "", nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return "", fmt.Errorf("subscription_id must be a positive integer")
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// dryRunContext returns the request context, marked as a dry run when the query asks for one with dry_run.
+// Errors carry a message fit for the client.
+func dryRunContext(ctx *gin.Context) (context.Context, error) {
+	reqCtx := ctx.Request.Context()
+	v := ctx.Query("dry_run")
+	if v == "" {
+		return reqCtx, nil
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("dry_run must be true or false")
+	}
+	if dryRun {
+		reqCtx = client.WithDryRun(reqCtx)
+	}
+	return reqCtx, nil
+}
+
+func (h *AdminHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, subscription_service.ErrNotFound):
+		response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Subscription not found")
+	case errors.Is(err, subscription_service.ErrAlreadyConfirmed):
+		response.WriteErrorJSON(ctx, http.StatusConflict, err, "Already confirmed")
+	default:
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+	}
+}
+
+// parseSubscriptionFilter reads listing filters from the query string. Errors carry a message fit for the client.
+func parseSubscriptionFilter(ctx *gin.Context) (model.SubscriptionFilter, error) {
+	filter := model.SubscriptionFilter{
+		Email: strings.TrimSpace(ctx.Query("email")),
+		City:  strings.TrimSpace(ctx.Query("city")),
+	}
+
+	if v := ctx.Query("frequency"); v != "" {
+		if !validate.IsValidFrequency(v) {
+			return filter, fmt.Errorf("frequency must be 'hourly' or 'daily'")
+		}
+		filter.Frequency = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := ctx.Query("confirmed"); v != "" {
+		confirmed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("confirmed must be true or false")
+		}
+		filter.Confirmed = &confirmed
+	}
+	if v := ctx.Query("created_from"); v != "" {
+		from, err := parseFilterTime(v)
+		if err != nil {
+			return filter, fmt.Errorf("created_from must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		filter.CreatedFrom = &from
+	}
+	if v := ctx.Query("created_to"); v != "" {
+		to, err := parseFilterTime(v)
+		if err != nil {
+			return filter, fmt.Errorf("created_to must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		filter.CreatedTo = &to
+	}
+	limit, offset, err := parsePage(ctx)
+	if err != nil {
+		return filter, err
+	}
+	filter.Limit, filter.Offset = limit, offset
+	return filter, nil
+}
+
+// parsePage reads the limit and offset of a listing from the query string. Errors carry a message fit for the client.
+func parsePage(ctx *gin.Context) (limit, offset int, err error) {
+	limit = defaultAdminPageSize
+	if v := ctx.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAdminPageSize {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxAdminPageSize)
+		}
+	}
+	if v := ctx.Query("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must not be negative")
+		}
+	}
+	return limit, offset, nil
+}
+
+// parseFilterTime accepts a full RFC 3339 timestamp or a bare date, which means midnight UTC.
+func parseFilterTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
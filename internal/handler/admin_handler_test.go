@@ -0,0 +1,492 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/services/bulk_service"
+	"Weather-API-Application/internal/services/capture_service"
+	"Weather-API-Application/internal/services/delivery_service"
+	"Weather-API-Application/internal/services/scheduler_service"
+	"Weather-API-Application/internal/services/subscription_service"
+	"Weather-API-Application/internal/services/suppression_service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TokenSecret: "secret", AdminUser: "admin", AdminPassword: "pass", SchedulerInstanceID: "node-a"}
+	confirmed := true
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		user, password string
+		mockSetup      func(*repository.MockSubscriptionRepository)
+		suppressions   func(*repository.MockSuppressionRepository)
+		deliveries     func(*repository.MockDeliveryRepository)
+		scheduler      func(*repository.MockSchedulerRepository)
+		captures       func(*repository.MockCaptureRepository)
+		body           string
+		expectedStatus int
+		expectedBody   string
+		reason         string
+	}{
+		{
+			name:           "Error - missing credentials",
+			method:         http.MethodGet,
+			path:           "/api/admin/subscriptions",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Unauthorized",
+			reason:         "Admin endpoints must not be reachable without credentials",
+		},
+		{
+			name:           "Error - wrong password",
+			method:         http.MethodGet,
+			path:           "/api/admin/subscriptions",
+			user:           "admin",
+			password:       "wrong",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Unauthorized",
+			reason:         "Both user and password must match",
+		},
+		{
+			name:     "Success - filters are passed to the repository",
+			method:   http.MethodGet,
+			path:     "/api/admin/subscriptions?email=User@Example.com&frequency=Hourly&confirmed=true&created_from=2025-01-01&limit=10&offset=20",
+			user:     "admin",
+			password: "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				filter := model.SubscriptionFilter{
+					Email:       "User@Example.com",
+					Frequency:   "hourly",
+					Confirmed:   &confirmed,
+					CreatedFrom: &from,
+					Limit:       10,
+					Offset:      20,
+				}
+				subs := []*model.Subscription{{ID: "5", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true}}
+				m.On("List", mock.Anything, filter).Return(subs, 21, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"total":21`,
+			reason:         "Listing must apply every filter and report the total for pagination",
+		},
+		{
+			name:           "Error - page size above the maximum",
+			method:         http.MethodGet,
+			path:           "/api/admin/subscriptions?limit=1000",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "limit must be between 1 and 200",
+			reason:         "Unbounded pages would let one request read the whole table",
+		},
+		{
+			name:     "Error - force-confirming a confirmed subscription",
+			method:   http.MethodPost,
+			path:     "/api/admin/subscriptions/5/confirm",
+			user:     "admin",
+			password: "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("GetByID", mock.Anything, "5").Return(&model.Subscription{ID: "5", Confirmed: true}, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "Already confirmed",
+			reason:         "Confirming again would rotate the unsubscribe token for nothing",
+		},
+		{
+			name:     "Error - sending now to a pending subscription",
+			method:   http.MethodPost,
+			path:     "/api/admin/subscriptions/6/send-now",
+			user:     "admin",
+			password: "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("GetByID", mock.Anything, "6").Return(&model.Subscription{ID: "6"}, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "not confirmed",
+			reason:         "Updates are only sent to confirmed addresses",
+		},
+		{
+			name:     "Error - unknown subscription",
+			method:   http.MethodDelete,
+			path:     "/api/admin/subscriptions/404",
+			user:     "admin",
+			password: "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("GetByID", mock.Anything, "404").Return(nil, repository.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Subscription not found",
+		},
+		{
+			name:      "Success - list suppressions",
+			method:    http.MethodGet,
+			path:      "/api/admin/suppressions?limit=10",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			suppressions: func(m *repository.MockSuppressionRepository) {
+				items := []*model.Suppression{{Email: "bounced@example.com", Reason: model.SuppressedHardBounce}}
+				m.On("List", mock.Anything, 10, 0).Return(items, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"reason":"hard_bounce"`,
+		},
+		{
+			name:      "Success - suppressions ignore subscription filters",
+			method:    http.MethodGet,
+			path:      "/api/admin/suppressions?frequency=weekly&created_from=yesterday",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			suppressions: func(m *repository.MockSuppressionRepository) {
+				m.On("List", mock.Anything, 50, 0).Return(nil, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"items":[]`,
+			reason:         "Only paging applies to suppressions, so unrelated parameters are not validated",
+		},
+		{
+			name:      "Error - lift unknown suppression",
+			method:    http.MethodDelete,
+			path:      "/api/admin/suppressions/fine@example.com",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			suppressions: func(m *repository.MockSuppressionRepository) {
+				m.On("Lift", mock.Anything, "fine@example.com").Return(repository.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Address not suppressed",
+		},
+		{
+			name:      "Success - deliveries filtered by subscription and status",
+			method:    http.MethodGet,
+			path:      "/api/admin/deliveries?subscription_id=5&status=failed",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				filter := model.DeliveryFilter{SubscriptionID: "5", Status: model.DeliveryFailed, Limit: 50}
+				items := []*model.Delivery{{ID: 1, SubscriptionID: "5", Status: model.DeliveryFailed, ProviderResponse: "451 try again later"}}
+				m.On("List", mock.Anything, filter).Return(items, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"provider_response":"451 try again later"`,
+		},
+		{
+			name:      "Success - deliveries ignore subscription filters",
+			method:    http.MethodGet,
+			path:      "/api/admin/deliveries?confirmed=maybe&offset=20",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				m.On("List", mock.Anything, model.DeliveryFilter{Limit: 50, Offset: 20}).Return(nil, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"offset":20`,
+			reason:         "Deliveries have no confirmed filter, so the parameter is not validated",
+		},
+		{
+			name:           "Error - non-numeric subscription_id filter for deliveries",
+			method:         http.MethodGet,
+			path:           "/api/admin/deliveries?subscription_id=abc",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "subscription_id must be a positive integer",
+			reason:         "The filter is compared against an integer column",
+		},
+		{
+			name:           "Error - non-numeric subscription_id filter for schedules",
+			method:         http.MethodGet,
+			path:           "/api/admin/schedules?subscription_id=1.5",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "subscription_id must be a positive integer",
+			reason:         "The filter is compared against an integer column",
+		},
+		{
+			name:           "Error - unknown delivery status",
+			method:         http.MethodGet,
+			path:           "/api/admin/deliveries?status=bounced",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "status must be",
+		},
+		{
+			name:      "Success - recent deliveries of a subscriber",
+			method:    http.MethodGet,
+			path:      "/api/admin/subscribers/user@example.com/deliveries?limit=5",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				m.On("List", mock.Anything, model.DeliveryFilter{Email: "user@example.com", Limit: 5}).Return(nil, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+			reason:         "A subscriber without deliveries gets an empty list, not null",
+		},
+		{
+			name:      "Success - schedules of a subscriber",
+			method:    http.MethodGet,
+			path:      "/api/admin/schedules?email=user@example.com",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			scheduler: func(m *repository.MockSchedulerRepository) {
+				filter := model.ScheduleFilter{Email: "user@example.com", Limit: 50}
+				items := []*model.Schedule{{SubscriptionID: "5", Frequency: "hourly", LastOutcome: model.DeliveryRetrying, ConsecutiveFailures: 2}}
+				m.On("ListSchedules", mock.Anything, filter).Return(items, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"consecutive_failures":2`,
+		},
+		{
+			name:      "Success - scheduler paused with a reason",
+			method:    http.MethodPost,
+			path:      "/api/admin/scheduler/pause",
+			user:      "admin",
+			password:  "pass",
+			body:      `{"reason":"SMTP provider incident"}`,
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			scheduler: func(m *repository.MockSchedulerRepository) {
+				state := &model.SchedulerState{Paused: true, PausedAt: &from, PauseReason: "SMTP provider incident"}
+				m.On("SetPaused", mock.Anything, true, "SMTP provider incident", mock.Anything).Return(state, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"paused":true`,
+		},
+		{
+			name:      "Success - scheduler paused without a body",
+			method:    http.MethodPost,
+			path:      "/api/admin/scheduler/pause",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			scheduler: func(m *repository.MockSchedulerRepository) {
+				m.On("SetPaused", mock.Anything, true, "", mock.Anything).Return(&model.SchedulerState{Paused: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"instance":"node-a"`,
+		},
+		{
+			name:      "Success - scheduler resumed",
+			method:    http.MethodPost,
+			path:      "/api/admin/scheduler/resume",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			scheduler: func(m *repository.MockSchedulerRepository) {
+				m.On("SetPaused", mock.Anything, false, "", mock.Anything).Return(&model.SchedulerState{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"paused":false`,
+		},
+		{
+			name:      "Success - dead letters",
+			method:    http.MethodGet,
+			path:      "/api/admin/dead-letters?limit=10",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				filter := model.DeliveryFilter{Status: model.DeliveryFailed, Limit: 10}
+				items := []*model.Delivery{{ID: 7, Status: model.DeliveryFailed, Failure: model.FailurePermanent}}
+				m.On("List", mock.Anything, filter).Return(items, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"failure":"permanent"`,
+		},
+		{
+			name:      "Success - dead letter replayed",
+			method:    http.MethodPost,
+			path:      "/api/admin/dead-letters/7/replay",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				m.On("Requeue", mock.Anything, int64(7), mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "Delivery queued for replay.",
+		},
+		{
+			name:      "Error - replay of a delivery that is not a dead letter",
+			method:    http.MethodPost,
+			path:      "/api/admin/dead-letters/8/replay",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			deliveries: func(m *repository.MockDeliveryRepository) {
+				m.On("Requeue", mock.Anything, int64(8), mock.Anything).Return(repository.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Dead letter not found",
+		},
+		{
+			name:           "Error - non-numeric subscription id",
+			method:         http.MethodGet,
+			path:           "/api/admin/subscriptions/abc",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "subscription id must be a positive integer",
+			reason:         "An id that is not an integer must not reach the database as a failed cast",
+		},
+		{
+			name:           "Error - non-numeric subscription id for send-now",
+			method:         http.MethodPost,
+			path:           "/api/admin/subscriptions/1x/send-now",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "subscription id must be a positive integer",
+			reason:         "Every endpoint taking a subscription id validates it",
+		},
+		{
+			name:           "Error - invalid dead letter id",
+			method:         http.MethodPost,
+			path:           "/api/admin/dead-letters/abc/replay",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "delivery id must be a positive integer",
+		},
+		{
+			name:     "Success - resending a confirmation as a dry run captures it",
+			method:   http.MethodPost,
+			path:     "/api/admin/subscriptions/6/resend-confirmation?dry_run=true",
+			user:     "admin",
+			password: "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("GetByID", mock.Anything, "6").Return(&model.Subscription{ID: "6", Email: "user@example.com", City: "Kyiv"}, nil)
+			},
+			captures: func(m *repository.MockCaptureRepository) {
+				m.On("Save", mock.Anything, mock.MatchedBy(func(e *model.CapturedEmail) bool {
+					return e.To == "user@example.com" && e.Subject == config.ConfirmSubject &&
+						strings.Contains(e.HTML, "http://localhost:8080/api/subscription/confirm/") &&
+						strings.Contains(e.Text, "http://localhost:8080/api/subscription/confirm/")
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Confirmation email captured (dry run).",
+			reason:         "A dry run renders the email but neither sends it nor replaces the subscriber's token",
+		},
+		{
+			name:           "Error - invalid dry_run",
+			method:         http.MethodPost,
+			path:           "/api/admin/subscriptions/6/send-now?dry_run=maybe",
+			user:           "admin",
+			password:       "pass",
+			mockSetup:      func(m *repository.MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "dry_run must be true or false",
+		},
+		{
+			name:      "Success - list captured emails",
+			method:    http.MethodGet,
+			path:      "/api/admin/captured-emails?email=User@Example.com&limit=10",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			captures: func(m *repository.MockCaptureRepository) {
+				filter := model.CapturedEmailFilter{Email: "User@Example.com", Limit: 10}
+				items := []*model.CapturedEmail{{ID: 3, To: "user@example.com", Subject: "Kyiv forecast", HTML: "<p>Sunny</p>", Text: "Sunny"}}
+				m.On("List", mock.Anything, filter).Return(items, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"subject":"Kyiv forecast"`,
+		},
+		{
+			name:      "Success - clear captured emails",
+			method:    http.MethodDelete,
+			path:      "/api/admin/captured-emails",
+			user:      "admin",
+			password:  "pass",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {},
+			captures: func(m *repository.MockCaptureRepository) {
+				m.On("DeleteAll", mock.Anything).Return(int64(4), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"deleted":4`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			tt.mockSetup(repo)
+			suppressionRepo := new(repository.MockSuppressionRepository)
+			if tt.suppressions != nil {
+				tt.suppressions(suppressionRepo)
+			}
+
+			captureRepo := new(repository.MockCaptureRepository)
+			if tt.captures != nil {
+				tt.captures(captureRepo)
+			}
+			captureSvc := capture_service.NewCaptureService(captureRepo, cfg)
+
+			router := gin.New()
+			// No email reaches a mail server: the cases that send are dry runs
+			subSvc := subscription_service.NewSubscriptionService(repo, captureSvc.Route(nil), cfg)
+			schedulerRepo := new(repository.MockSchedulerRepository)
+			if tt.scheduler != nil {
+				tt.scheduler(schedulerRepo)
+			}
+			schedulerSvc := scheduler_service.NewSchedulerService(repo, nil, cfg).WithControl(schedulerRepo)
+			bulkSvc := bulk_service.NewBulkService(repo, nil, cfg)
+			suppressionSvc := suppression_service.NewSuppressionService(suppressionRepo, cfg)
+			deliveryRepo := new(repository.MockDeliveryRepository)
+			if tt.deliveries != nil {
+				tt.deliveries(deliveryRepo)
+			}
+			deliverySvc := delivery_service.NewDeliveryService(deliveryRepo, cfg)
+			NewAdminHandler(cfg, subSvc, schedulerSvc, bulkSvc, suppressionSvc, deliverySvc, captureSvc).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, tt.reason)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			repo.AssertExpectations(t)
+			suppressionRepo.AssertExpectations(t)
+			deliveryRepo.AssertExpectations(t)
+			schedulerRepo.AssertExpectations(t)
+			captureRepo.AssertExpectations(t)
+		})
+	}
+}
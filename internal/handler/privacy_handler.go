@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/middleware"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/services/privacy_service"
+	"Weather-API-Application/internal/utils/ratelimit"
+	"Weather-API-Application/internal/utils/response"
+	"Weather-API-Application/internal/utils/validate"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	config         *config.Config
+	privacyService *privacy_service.PrivacyService
+	requestLimiter *ratelimit.Limiter
+}
+
+func NewPrivacyHandler(cfg *config.Config, privacySvc *privacy_service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		config:         cfg,
+		privacyService: privacySvc,
+		requestLimiter: ratelimit.New(cfg.SubscribeRateLimit, cfg.SubscribeRateWindow),
+	}
+}
+
+// RegisterRoutes registers data-subject request endpoints.
+func (h *PrivacyHandler) RegisterRoutes(router *gin.Engine) {
+	privacy := router.Group("/api/privacy")
+	{
+		privacy.POST("/request", middleware.RateLimit(h.requestLimiter), h.RequestLink)
+		privacy.GET("/export/:token", h.Export)
+		privacy.POST("/erase/:token", h.Erase)
+	}
+}
+
+// RequestLink godoc
+// @Summary      Request a data export or erasure
+// @Description  Emails a time-limited link that exports or erases all data held about the email. The response is the same whether or not any data is held. An email can request one link per PRIVACY_REQUEST_COOLDOWN.
+// @Tags         privacy
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.PrivacyRequest  true  "Privacy request"
+// @Success      202  {string}  string  "Verification email sent if any data is held"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid input"
+// @Failure      429  {object}  response.ErrorResponse  "Too many requests, or a link was requested for the email recently"
+// @Failure      500  {object}  response.ErrorResponse  "Internal error"
+// @Router       /privacy/request [post]
+func (h *PrivacyHandler) RequestLink(ctx *gin.Context) {
+	var req model.PrivacyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Invalid input")
+		return
+	}
+	if !validate.IsValidEmail(req.Email) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("invalid email format"),
+			"Invalid email format")
+		return
+	}
+	if req.Action != privacy_service.ActionExport && req.Action != privacy_service.ActionErase {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("invalid privacy action: %q", req.Action),
+			"Action must be 'export' or 'erase'")
+		return
+	}
+
+	if err := h.privacyService.RequestLink(ctx.Request.Context(), req.Email, req.Action); err != nil {
+		var cooldown *privacy_service.RetryAfterError
+		if errors.As(err, &cooldown) {
+			response.WriteTooManyRequests(ctx, err, cooldown.RetryAfter, "A link was requested for this email recently, please check your inbox")
+			return
+		}
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If we hold data for this email, a verification link has been sent to it."})
+}
+
+// Export godoc
+// @Summary      Download personal data
+// @Description  Returns all data held about the email the export link was issued for.
+// @Tags         privacy
+// @Produce      json
+// @Param        token  path      string  true  "Export token"
+// @Success      200    {object}  model.DataExport  "Personal data"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Failure      410    {object}  response.ErrorResponse  "Token expired"
+// @Router       /privacy/export/{token} [get]
+func (h *PrivacyHandler) Export(ctx *gin.Context) {
+	export, err := h.privacyService.Export(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="weather-data-export.json"`)
+	ctx.JSON(http.StatusOK, export)
+}
+
+// Erase godoc
+// @Summary      Erase personal data
+// @Description  Permanently deletes all subscriptions and data tied to the email the erasure link was issued for and stops its deliveries.
+// @Tags         privacy
+// @Produce      json
+// @Param        token  path      string  true  "Erasure token"
+// @Success      200    {object}  map[string]interface{}  "Data erased"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Failure      410    {object}  response.ErrorResponse  "Token expired"
+// @Router       /privacy/erase/{token} [post]
+func (h *PrivacyHandler) Erase(ctx *gin.Context) {
+	removed, err := h.privacyService.Erase(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":               "Your data has been erased.",
+		"subscriptions_removed": removed,
+	})
+}
+
+func (h *PrivacyHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, privacy_service.ErrInvalidToken):
+		response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Token not found")
+	case errors.Is(err, privacy_service.ErrTokenExpired):
+		response.WriteErrorJSON(ctx, http.StatusGone, err, "Link expired, please request a new one")
+	default:
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+	}
+}
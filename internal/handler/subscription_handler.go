@@ -4,10 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"Weather-API-Application/internal/client"
 	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/middleware"
 	"Weather-API-Application/internal/model"
 	"Weather-API-Application/internal/services/subscription_service"
+	"Weather-API-Application/internal/utils/ratelimit"
 	"Weather-API-Application/internal/utils/response"
 	"Weather-API-Application/internal/utils/validate"
 
@@ -17,12 +24,14 @@ import (
 type SubscriptionHandler struct {
 	config              *config.Config
 	subscriptionService *subscription_service.SubscriptionService
+	subscribeLimiter    *ratelimit.Limiter
 }
 
 func NewSubscriptionHandler(cfg *config.Config, subSvc *subscription_service.SubscriptionService) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		config:              cfg,
 		subscriptionService: subSvc,
+		subscribeLimiter:    ratelimit.New(cfg.SubscribeRateLimit, cfg.SubscribeRateWindow),
 	}
 }
 
@@ -30,9 +39,14 @@ func NewSubscriptionHandler(cfg *config.Config, subSvc *subscription_service.Sub
 func (h *SubscriptionHandler) RegisterRoutes(router *gin.Engine) {
 	subscription := router.Group("/api/subscription")
 	{
-		subscription.POST("/subscribe", h.Subscribe)
+		subscription.POST("/subscribe", middleware.RateLimit(h.subscribeLimiter), h.Subscribe)
 		subscription.GET("/confirm/:token", h.ConfirmSubscription)
 		subscription.GET("/unsubscribe/:token", h.Unsubscribe)
+		subscription.POST("/unsubscribe/:token", h.OneClickUnsubscribe)
+		subscription.POST("/pause/:token", h.Pause)
+		subscription.POST("/resume/:token", h.Resume)
+		subscription.PUT("/quiet-hours/:token", h.SetQuietHours)
+		subscription.DELETE("/quiet-hours/:token", h.ClearQuietHours)
 	}
 }
 
@@ -45,7 +59,10 @@ func (h *SubscriptionHandler) RegisterRoutes(router *gin.Engine) {
 // @Param        subscription  body   model.Subscription  true  "Subscription request"
 // @Success      200  {object}  model.Subscription  "Subscription request accepted. Confirmation email sent."
 // @Failure      400  {object}  response.ErrorResponse  "Invalid input"
-// @Failure      409  {object}  response.ErrorResponse  "Email already subscribed"
+// @Failure      409  {object}  response.ErrorResponse  "Email already subscribed or confirmed subscriptions reached the limit"
+// @Failure      422  {object}  response.ErrorResponse  "Email address is suppressed"
+// @Failure      429  {object}  response.ErrorResponse  "Too many requests, pending subscriptions reached the limit or confirmation resent too recently"
+// @Header       429  {integer}  Retry-After  "Seconds until the request may succeed"
 // @Failure      500  {object}  response.ErrorResponse  "Internal error"
 // @Router       /subscription/subscribe [post]
 func (h *SubscriptionHandler) Subscribe(ctx *gin.Context) {
@@ -74,12 +91,43 @@ func (h *SubscriptionHandler) Subscribe(ctx *gin.Context) {
 			"Frequency must be 'hourly' or 'daily'")
 		return
 	}
+	if req.QuietHours != nil {
+		if !strings.EqualFold(strings.TrimSpace(req.Frequency), "hourly") {
+			response.WriteErrorJSON(ctx, http.StatusBadRequest,
+				subscription_service.ErrQuietHoursRequireHourly,
+				"Quiet hours are only supported for hourly subscriptions")
+			return
+		}
+		if !validate.IsValidQuietHours(req.QuietHours.Start, req.QuietHours.End, req.QuietHours.Timezone) {
+			response.WriteErrorJSON(ctx, http.StatusBadRequest,
+				fmt.Errorf("invalid quiet hours"),
+				"Quiet hours need distinct HH:MM start and end times and a valid IANA timezone")
+			return
+		}
+	}
 
 	if err := h.subscriptionService.Subscribe(ctx.Request.Context(), &req); err != nil {
 		switch {
 		case errors.Is(err, subscription_service.ErrSubscriptionExists):
 			response.WriteErrorJSON(ctx, http.StatusConflict, err, "Email already subscribed")
 			return
+		case errors.Is(err, subscription_service.ErrSubscriptionLimit):
+			msg := fmt.Sprintf("An email can have at most %d subscriptions", h.config.MaxSubscriptionsPerEmail)
+			if wait := retryAfter(err); wait > 0 {
+				// Pending subscriptions fill the cap and free their slot once their confirmation link expires
+				response.WriteTooManyRequests(ctx, err, wait, msg)
+				return
+			}
+			response.WriteErrorJSON(ctx, http.StatusConflict, err, msg)
+			return
+		case errors.Is(err, subscription_service.ErrResendCooldown):
+			response.WriteTooManyRequests(ctx, err, retryAfter(err), "Confirmation email was sent recently, please check your inbox")
+			return
+		case errors.Is(err, client.ErrSuppressed):
+			// The pending subscription is left for the janitor, like any other that is never confirmed
+			response.WriteErrorJSON(ctx, http.StatusUnprocessableEntity, err,
+				"Emails to this address bounced or were reported as spam; contact support to receive email again")
+			return
 		default:
 			response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
 			return
@@ -91,25 +139,26 @@ func (h *SubscriptionHandler) Subscribe(ctx *gin.Context) {
 
 // ConfirmSubscription godoc
 // @Summary      Confirm subscription
-// @Description  Confirms a subscription using the token from the confirmation email.
+// @Description  Confirms a subscription using the token from the confirmation email and returns the unsubscribe link.
 // @Tags         subscription
 // @Produce      json
 // @Param        token  path      string  true  "Confirmation token"
-// @Success      200    {string}  string  "Subscription confirmed successfully"
+// @Success      200    {object}  map[string]string  "Subscription confirmed successfully"
 // @Failure      400    {object}  response.ErrorResponse  "Invalid token"
 // @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Failure      410    {object}  response.ErrorResponse  "Token expired"
 // @Router       /subscription/confirm/{token} [get]
 func (h *SubscriptionHandler) ConfirmSubscription(ctx *gin.Context) {
 	token := ctx.Param("token")
 
-	_, err := h.subscriptionService.ConfirmSubscription(ctx.Request.Context(), token)
+	sub, err := h.subscriptionService.ConfirmSubscription(ctx.Request.Context(), token)
 	if err != nil {
 		switch {
 		case errors.Is(err, subscription_service.ErrNotFound):
             response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Token not found")
 			return
-		case errors.Is(err, subscription_service.ErrAlreadyConfirmed):
-			response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Already confirmed")
+		case errors.Is(err, subscription_service.ErrTokenExpired):
+			response.WriteErrorJSON(ctx, http.StatusGone, err, "Token expired")
 			return
 		default:
 			response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
@@ -117,20 +166,43 @@ func (h *SubscriptionHandler) ConfirmSubscription(ctx *gin.Context) {
 		}
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Subscription confirmed."})
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":         "Subscription confirmed.",
+		"unsubscribe_url": config.BuildUnsubscribeURL(h.config.BaseURL, h.subscriptionService.UnsubscribeToken(sub)),
+	})
 }
 
 // Unsubscribe godoc
-// @Summary      Unsubscribe from weather updates
-// @Description  Unsubscribes an email using the provided token.
+// @Summary      Open the unsubscribe confirmation page
+// @Description  Redirects to the page that asks the subscriber to confirm, so mail scanners following links cannot unsubscribe. Emails sent before the page existed link here.
 // @Tags         subscription
-// @Produce      json
 // @Param        token  path      string  true  "Unsubscribe token"
-// @Success      200    {string}  string  "Unsubscribed successfully"
-// @Failure      400    {object}  response.ErrorResponse  "Invalid token"
-// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Success      302    {string}  string  "Redirect to the confirmation page"
 // @Router       /subscription/unsubscribe/{token} [get]
 func (h *SubscriptionHandler) Unsubscribe(ctx *gin.Context) {
+	ctx.Redirect(http.StatusFound, config.BuildUnsubscribeURL("", url.QueryEscape(ctx.Param("token"))))
+}
+
+// OneClickUnsubscribe godoc
+// @Summary      Unsubscribe from weather updates (RFC 8058)
+// @Description  Unsubscribes immediately when a mail client posts the List-Unsubscribe-Post form, or the confirmation page posts the same form.
+// @Tags         subscription
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token             path      string  true  "Unsubscribe token"
+// @Param        List-Unsubscribe  formData  string  true  "Must be One-Click"
+// @Success      200    {string}  string  "Unsubscribed successfully"
+// @Failure      400    {object}  response.ErrorResponse  "Invalid one-click request"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Router       /subscription/unsubscribe/{token} [post]
+func (h *SubscriptionHandler) OneClickUnsubscribe(ctx *gin.Context) {
+	if ctx.PostForm("List-Unsubscribe") != "One-Click" {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("missing List-Unsubscribe=One-Click form value"),
+			"Invalid one-click unsubscribe request")
+		return
+	}
+
 	token := ctx.Param("token")
 	if err := h.subscriptionService.Unsubscribe(ctx.Request.Context(), token); err != nil {
 		switch {
@@ -144,3 +216,140 @@ func (h *SubscriptionHandler) Unsubscribe(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
 }
+
+// Pause godoc
+// @Summary      Pause weather updates
+// @Description  Suspends deliveries for the given number of days using the pause token from an update email. The email links to a confirmation page that posts here.
+// @Tags         subscription
+// @Produce      json
+// @Param        token  path      string  true  "Pause token"
+// @Param        days   query     int     true  "Number of days to pause for"
+// @Success      200    {object}  map[string]string  "Subscription paused"
+// @Failure      400    {object}  response.ErrorResponse  "Invalid number of days"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Router       /subscription/pause/{token} [post]
+func (h *SubscriptionHandler) Pause(ctx *gin.Context) {
+	token := ctx.Param("token")
+
+	days, err := strconv.Atoi(ctx.Query("days"))
+	if err != nil || !validate.IsValidPauseDays(days, h.config.MaxPauseDays) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("invalid pause days: %q", ctx.Query("days")),
+			fmt.Sprintf("Days must be between 1 and %d", h.config.MaxPauseDays))
+		return
+	}
+
+	sub, err := h.subscriptionService.Pause(ctx.Request.Context(), token, days)
+	if err != nil {
+		switch {
+		case errors.Is(err, subscription_service.ErrNotFound):
+			response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Token not found")
+			return
+		default:
+			response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      "Subscription paused.",
+		"paused_until": sub.PausedUntil.UTC().Format(time.RFC3339),
+		"resume_url":   config.BuildResumeURL(h.config.BaseURL, h.subscriptionService.PauseToken(sub)),
+	})
+}
+
+// Resume godoc
+// @Summary      Resume weather updates
+// @Description  Lifts a pause before it expires. The pause response links to a confirmation page that posts here.
+// @Tags         subscription
+// @Produce      json
+// @Param        token  path      string  true  "Pause token"
+// @Success      200    {string}  string  "Subscription resumed"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Router       /subscription/resume/{token} [post]
+func (h *SubscriptionHandler) Resume(ctx *gin.Context) {
+	token := ctx.Param("token")
+
+	if _, err := h.subscriptionService.Resume(ctx.Request.Context(), token); err != nil {
+		switch {
+		case errors.Is(err, subscription_service.ErrNotFound):
+			response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Token not found")
+			return
+		default:
+			response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Subscription resumed."})
+}
+
+// SetQuietHours godoc
+// @Summary      Set quiet hours
+// @Description  Sets the daily window during which hourly updates are skipped, using the management token from an update email.
+// @Tags         subscription
+// @Accept       json
+// @Produce      json
+// @Param        token        path      string             true  "Management token"
+// @Param        quiet_hours  body      model.QuietHours   true  "Quiet hours"
+// @Success      200    {object}  model.QuietHours  "Quiet hours updated"
+// @Failure      400    {object}  response.ErrorResponse  "Invalid quiet hours"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Router       /subscription/quiet-hours/{token} [put]
+func (h *SubscriptionHandler) SetQuietHours(ctx *gin.Context) {
+	var req model.QuietHours
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Invalid input")
+		return
+	}
+	if !validate.IsValidQuietHours(req.Start, req.End, req.Timezone) {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest,
+			fmt.Errorf("invalid quiet hours"),
+			"Quiet hours need distinct HH:MM start and end times and a valid IANA timezone")
+		return
+	}
+
+	sub, err := h.subscriptionService.SetQuietHours(ctx.Request.Context(), ctx.Param("token"), &req)
+	if err != nil {
+		h.writeQuietHoursError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, sub.QuietHours)
+}
+
+// ClearQuietHours godoc
+// @Summary      Remove quiet hours
+// @Description  Removes the quiet hours so hourly updates are delivered around the clock.
+// @Tags         subscription
+// @Produce      json
+// @Param        token  path      string  true  "Management token"
+// @Success      200    {string}  string  "Quiet hours removed"
+// @Failure      404    {object}  response.ErrorResponse  "Token not found"
+// @Router       /subscription/quiet-hours/{token} [delete]
+func (h *SubscriptionHandler) ClearQuietHours(ctx *gin.Context) {
+	if _, err := h.subscriptionService.SetQuietHours(ctx.Request.Context(), ctx.Param("token"), nil); err != nil {
+		h.writeQuietHoursError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Quiet hours removed."})
+}
+
+func (h *SubscriptionHandler) writeQuietHoursError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, subscription_service.ErrNotFound):
+		response.WriteErrorJSON(ctx, http.StatusNotFound, err, "Token not found")
+	case errors.Is(err, subscription_service.ErrQuietHoursRequireHourly):
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Quiet hours are only supported for hourly subscriptions")
+	default:
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+	}
+}
+
+// retryAfter extracts the retry delay from a service refusal, if it carries one.
+func retryAfter(err error) time.Duration {
+	var retryErr *subscription_service.RetryAfterError
+	if errors.As(err, &retryErr) {
+		return retryErr.RetryAfter
+	}
+	return 0
+}
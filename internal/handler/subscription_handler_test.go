@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/services/subscription_service"
+	"Weather-API-Application/internal/utils/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneClickUnsubscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TokenSecret: "secret"}
+	signed := token.NewHasher(cfg.TokenSecret).Sign(token.PurposeUnsubscribe, "7")
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		mockSetup      func(*repository.MockSubscriptionRepository)
+		expectedStatus int
+		expectedBody   string
+		reason         string
+	}{
+		{
+			name: "Success - one-click form unsubscribes",
+			form: url.Values{"List-Unsubscribe": {"One-Click"}},
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				sub := &model.Subscription{ID: "7", Email: "user@example.com", City: "Kyiv", Confirmed: true}
+				m.On("GetByID", mock.Anything, "7").Return(sub, nil)
+				m.On("DeleteByID", mock.Anything, "7").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Unsubscribed successfully",
+			reason:         "RFC 8058 POST must unsubscribe without a confirmation page",
+		},
+		{
+			name: "Error - missing one-click form value",
+			form: url.Values{},
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				// Do not setup mock - repository must not be called
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid one-click unsubscribe request",
+			reason:         "Only List-Unsubscribe=One-Click posts are accepted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			tt.mockSetup(repo)
+
+			router := gin.New()
+			NewSubscriptionHandler(cfg, subscription_service.NewSubscriptionService(repo, nil, cfg)).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/subscription/unsubscribe/"+signed, strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, tt.reason)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnsubscribeLinkDoesNotUnsubscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TokenSecret: "secret"}
+	signed := token.NewHasher(cfg.TokenSecret).Sign(token.PurposeUnsubscribe, "7")
+	repo := new(repository.MockSubscriptionRepository)
+
+	router := gin.New()
+	NewSubscriptionHandler(cfg, subscription_service.NewSubscriptionService(repo, nil, cfg)).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/subscription/unsubscribe/"+signed, nil))
+
+	require.Equal(t, http.StatusFound, w.Code, "Mail scanners following the link must not unsubscribe")
+	assert.Equal(t, "/static/unsubscribe.html?token="+url.QueryEscape(signed), w.Header().Get("Location"))
+	repo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestSubscribeQuietHoursValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TokenSecret: "secret"}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		reason         string
+	}{
+		{
+			name:           "Error - quiet hours on daily subscription",
+			body:           `{"email":"user@example.com","city":"Kyiv","frequency":"daily","quiet_hours":{"start":"22:00","end":"07:00","timezone":"Europe/Kyiv"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "only supported for hourly",
+			reason:         "Daily updates are sent once a day at a fixed hour, quiet hours make no sense there",
+		},
+		{
+			name:           "Error - malformed quiet hours",
+			body:           `{"email":"user@example.com","city":"Kyiv","frequency":"hourly","quiet_hours":{"start":"10pm","end":"07:00","timezone":"Europe/Kyiv"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "HH:MM",
+			reason:         "Times must use the 24-hour HH:MM format",
+		},
+		{
+			name:           "Error - unknown timezone",
+			body:           `{"email":"user@example.com","city":"Kyiv","frequency":"hourly","quiet_hours":{"start":"22:00","end":"07:00","timezone":"Kyiv"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "IANA timezone",
+			reason:         "Timezone must be an IANA name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+
+			router := gin.New()
+			NewSubscriptionHandler(cfg, subscription_service.NewSubscriptionService(repo, nil, cfg)).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/subscription/subscribe", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, tt.reason)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscribeLimitStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		BaseURL:                  "http://localhost:8080",
+		TokenSecret:              "secret",
+		ConfirmTokenTTL:          24 * time.Hour,
+		MaxSubscriptionsPerEmail: 1,
+	}
+
+	tests := []struct {
+		name           string
+		existing       []*model.Subscription
+		expectedStatus int
+		retryAfter     bool
+		reason         string
+	}{
+		{
+			name: "Error - pending subscription at the cap",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			retryAfter:     true,
+			reason:         "The slot frees up once the pending confirmation link expires",
+		},
+		{
+			name: "Error - confirmed subscription at the cap",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+			},
+			expectedStatus: http.StatusConflict,
+			reason:         "Waiting does not lift a cap held by confirmed subscriptions, so no retry is offered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			repo.On("ListByEmail", mock.Anything, "user@example.com").Return(tt.existing, nil)
+
+			router := gin.New()
+			NewSubscriptionHandler(cfg, subscription_service.NewSubscriptionService(repo, nil, cfg)).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/subscription/subscribe",
+				strings.NewReader(`{"email":"user@example.com","city":"Kyiv","frequency":"daily"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, tt.reason)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After") != "")
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPauseAndResumeRequirePost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TokenSecret: "secret", MaxPauseDays: 30}
+	signed := token.NewHasher(cfg.TokenSecret).Sign(token.PurposePause, "7")
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		mockSetup      func(repo *repository.MockSubscriptionRepository)
+		expectedStatus int
+		expectedBody   string
+		reason         string
+	}{
+		{
+			name:           "Error - GET does not pause",
+			method:         http.MethodGet,
+			path:           "/api/subscription/pause/" + signed + "?days=7",
+			expectedStatus: http.StatusNotFound,
+			reason:         "Mail scanners following the links in an email must not pause the subscription",
+		},
+		{
+			name:           "Error - GET does not resume",
+			method:         http.MethodGet,
+			path:           "/api/subscription/resume/" + signed,
+			expectedStatus: http.StatusNotFound,
+			reason:         "Mail scanners following the resume link must not resume the subscription",
+		},
+		{
+			name:   "Success - POST pauses and links the resume page",
+			method: http.MethodPost,
+			path:   "/api/subscription/pause/" + signed + "?days=7",
+			mockSetup: func(repo *repository.MockSubscriptionRepository) {
+				repo.On("GetByID", mock.Anything, "7").Return(&model.Subscription{ID: "7", Confirmed: true}, nil)
+				repo.On("SetPausedUntil", mock.Anything, "7", mock.AnythingOfType("*time.Time")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "http://localhost:8080/static/resume.html?token=",
+			reason:         "The confirmation page posts here and offers the resume page",
+		},
+		{
+			name:   "Success - POST resumes",
+			method: http.MethodPost,
+			path:   "/api/subscription/resume/" + signed,
+			mockSetup: func(repo *repository.MockSubscriptionRepository) {
+				repo.On("GetByID", mock.Anything, "7").Return(&model.Subscription{ID: "7", Confirmed: true}, nil)
+				repo.On("SetPausedUntil", mock.Anything, "7", (*time.Time)(nil)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Subscription resumed.",
+			reason:         "The resume page posts here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo)
+			}
+
+			router := gin.New()
+			NewSubscriptionHandler(cfg, subscription_service.NewSubscriptionService(repo, nil, cfg)).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			require.Equal(t, tt.expectedStatus, w.Code, tt.reason)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			repo.AssertExpectations(t)
+		})
+	}
+}
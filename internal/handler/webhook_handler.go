@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/services/suppression_service"
+	"Weather-API-Application/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the raw body, keyed with WEBHOOK_SECRET.
+const SignatureHeader = "X-Webhook-Signature"
+
+const maxWebhookBytes = 1 << 20
+
+type WebhookHandler struct {
+	config             *config.Config
+	suppressionService *suppression_service.SuppressionService
+}
+
+func NewWebhookHandler(cfg *config.Config, suppressionSvc *suppression_service.SuppressionService) *WebhookHandler {
+	return &WebhookHandler{
+		config:             cfg,
+		suppressionService: suppressionSvc,
+	}
+}
+
+// RegisterRoutes registers endpoints called by the mail provider.
+func (h *WebhookHandler) RegisterRoutes(router *gin.Engine) {
+	router.POST("/api/webhooks/email-events", h.EmailEvents)
+}
+
+// EmailEvents godoc
+// @Summary      Report bounces and complaints
+// @Description  Accepts delivery events from the mail provider. Hard bounces and complaints suppress the address at once; soft bounces suppress it after repeated failures. The request must be signed in the X-Webhook-Signature header as sha256=<hex HMAC-SHA256 of the body>. A batch with any invalid event is rejected as a whole.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        X-Webhook-Signature  header    string                 true  "Body signature"
+// @Param        events               body      model.EmailEventBatch  true  "Email events"
+// @Success      200  {string}  string  "Events processed"
+// @Failure      400  {object}  response.ErrorResponse  "Invalid events"
+// @Failure      401  {object}  response.ErrorResponse  "Invalid signature"
+// @Failure      413  {object}  response.ErrorResponse  "Body too large"
+// @Failure      500  {object}  response.ErrorResponse  "Internal error"
+// @Router       /webhooks/email-events [post]
+func (h *WebhookHandler) EmailEvents(ctx *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.WriteErrorJSON(ctx, http.StatusRequestEntityTooLarge, err, "Body too large")
+			return
+		}
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Invalid input")
+		return
+	}
+
+	// The signature covers the raw bytes, so it is checked before the body is parsed
+	if err := h.suppressionService.VerifySignature(body, ctx.GetHeader(SignatureHeader)); err != nil {
+		response.WriteErrorJSON(ctx, http.StatusUnauthorized, err, "Invalid signature")
+		return
+	}
+
+	var batch model.EmailEventBatch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, "Invalid input")
+		return
+	}
+	if err := suppression_service.ValidateEvents(batch.Events); err != nil {
+		response.WriteErrorJSON(ctx, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	suppressed, err := h.suppressionService.RecordEvents(ctx.Request.Context(), batch.Events)
+	if err != nil {
+		response.WriteErrorJSON(ctx, http.StatusInternalServerError, err, "Internal server error")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("%d events processed.", len(batch.Events)),
+		"processed":  len(batch.Events),
+		"suppressed": suppressed,
+	})
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"Weather-API-Application/internal/logger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	listenRetryDelay    = time.Second
+	listenMaxRetryDelay = time.Minute
+)
+
+// Listener receives the notifications sent on one Postgres channel over a connection of its own,
+// reconnecting whenever that connection is lost. Notifications sent while it is disconnected are lost,
+// so after every (re)connect the handler is called with an empty payload to let it catch up.
+type Listener struct {
+	dsn     string
+	channel string
+	done    chan struct{}
+}
+
+func NewListener(dsn, channel string) *Listener {
+	return &Listener{dsn: dsn, channel: channel}
+}
+
+// Start listens in the background until ctx is cancelled, calling fn with the payload of every notification.
+func (l *Listener) Start(ctx context.Context, fn func(payload string)) {
+	l.done = make(chan struct{})
+	go l.run(ctx, fn)
+}
+
+// Wait waits until the listener has closed its connection after ctx passed to Start was cancelled.
+func (l *Listener) Wait(ctx context.Context) error {
+	if l.done == nil {
+		return nil
+	}
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("listener on %s not closed: %w", l.channel, ctx.Err())
+	}
+}
+
+func (l *Listener) run(ctx context.Context, fn func(payload string)) {
+	defer close(l.done)
+
+	delay := listenRetryDelay
+	for {
+		err := l.listen(ctx, fn, func() { delay = listenRetryDelay })
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error(ctx, fmt.Errorf("listening on %s failed: %w", l.channel, err),
+			slog.Duration("retry_in", delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, listenMaxRetryDelay)
+	}
+}
+
+// listen holds one connection until it fails or ctx is cancelled.
+func (l *Listener) listen(ctx context.Context, fn func(payload string), connected func()) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		conn.Close(closeCtx)
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+	connected()
+	logger.Info(ctx, "Listening for notifications", slog.String("channel", l.channel))
+	fn("")
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		fn(n.Payload)
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type CaptureRepository struct {
+	db *sql.DB
+}
+
+func NewCaptureRepository(db *sql.DB) repository.CaptureRepository {
+	return &CaptureRepository{db: db}
+}
+
+// Save stores a captured email and sets its ID.
+func (r *CaptureRepository) Save(ctx context.Context, email *model.CapturedEmail) error {
+	const query = `
+		INSERT INTO captured_emails (recipient, subject, html_body, text_body, headers, captured_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	headers, err := json.Marshal(email.Headers)
+	if err != nil {
+		return err
+	}
+	if email.Headers == nil {
+		headers = []byte("{}")
+	}
+	return r.db.QueryRowContext(ctx, query,
+		email.To, email.Subject, email.HTML, email.Text, headers, email.CapturedAt.UTC(),
+	).Scan(&email.ID)
+}
+
+// List returns one page of captured emails, most recent first, and the total number of matches.
+func (r *CaptureRepository) List(ctx context.Context, filter model.CapturedEmailFilter) ([]*model.CapturedEmail, int, error) {
+	const countQuery = `
+		SELECT COUNT(*) FROM captured_emails
+		WHERE $1 = '' OR LOWER(recipient) = LOWER($1)
+	`
+	const pageQuery = `
+		SELECT id, recipient, subject, html_body, text_body, headers, captured_at
+		FROM captured_emails
+		WHERE $1 = '' OR LOWER(recipient) = LOWER($1)
+		ORDER BY captured_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, filter.Email).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, pageQuery, filter.Email, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []*model.CapturedEmail
+	for rows.Next() {
+		var (
+			e       model.CapturedEmail
+			headers []byte
+		)
+		if err := rows.Scan(&e.ID, &e.To, &e.Subject, &e.HTML, &e.Text, &headers, &e.CapturedAt); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(headers, &e.Headers); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// DeleteAll removes every captured email and returns how many there were.
+func (r *CaptureRepository) DeleteAll(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM captured_emails`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const deliveryColumns = `id, subscription_id, email, city, kind, scheduled_for, status, attempts,
+	provider_response, failure, next_attempt_at, created_at, updated_at, sent_at`
+
+type DeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) repository.DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+// Queue stores the deliveries as queued and sets their IDs. A delivery already recorded for the same
+// subscription, kind and slot, e.g. one sent again after a crash, is queued again and keeps its history.
+func (r *DeliveryRepository) Queue(ctx context.Context, deliveries []*model.Delivery, at time.Time) error {
+	const query = `
+		INSERT INTO deliveries (subscription_id, email, city, kind, scheduled_for, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (subscription_id, kind, scheduled_for) DO UPDATE
+		SET status = EXCLUDED.status,
+		    next_attempt_at = NULL,
+		    claimed_by = NULL,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING id, attempts, created_at
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		err := tx.QueryRowContext(ctx, query,
+			d.SubscriptionID, d.Email, d.City, d.Kind, d.ScheduledFor.UTC(), model.DeliveryQueued, at.UTC(),
+		).Scan(&d.ID, &d.Attempts, &d.CreatedAt)
+		if err != nil {
+			return err
+		}
+		d.Status = model.DeliveryQueued
+		d.UpdatedAt = at
+	}
+	return tx.Commit()
+}
+
+// Finish records the outcome of sending the deliveries. Emails refused for a suppressed address or
+// skipped as no longer wanted were never attempted and do not count as an attempt.
+func (r *DeliveryRepository) Finish(ctx context.Context, ids []int64, outcome model.DeliveryOutcome) error {
+	const query = `
+		UPDATE deliveries
+		SET status = $2,
+		    provider_response = NULLIF($3, ''),
+		    failure = NULLIF($4, ''),
+		    next_attempt_at = $5,
+		    claimed_by = NULL,
+		    attempts = attempts + CASE WHEN $2 IN ('suppressed', 'skipped') THEN 0 ELSE 1 END,
+		    updated_at = $6,
+		    sent_at = CASE WHEN $2 = 'sent' THEN $6 ELSE sent_at END
+		WHERE id = ANY($1)
+	`
+	var nextAttemptAt *time.Time
+	if outcome.NextAttemptAt != nil {
+		next := outcome.NextAttemptAt.UTC()
+		nextAttemptAt = &next
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		ids, outcome.Status, outcome.ProviderResponse, outcome.Failure, nextAttemptAt, outcome.At.UTC())
+	return err
+}
+
+// ClaimRetries claims up to limit deliveries whose retry is due for owner, together with the other deliveries
+// of the same digest, by moving their next attempt to leaseUntil. Another instance only picks them up again
+// if their outcome has not been recorded by then.
+func (r *DeliveryRepository) ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error) {
+	const query = `
+		WITH due AS (
+			SELECT id, email, kind, scheduled_for
+			FROM deliveries
+			WHERE status = 'retrying' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			SELECT id FROM due
+			UNION
+			SELECT d.id
+			FROM deliveries d
+			JOIN due ON due.kind = 'digest' AND d.kind = 'digest'
+			        AND d.email = due.email AND d.scheduled_for = due.scheduled_for
+			WHERE d.status = 'retrying'
+		)
+		UPDATE deliveries
+		SET next_attempt_at = $2,
+		    claimed_by = $4
+		WHERE id IN (SELECT id FROM claimed)
+		RETURNING ` + deliveryColumns + `
+	`
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), leaseUntil.UTC(), limit, owner)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// RenewRetryClaims extends every retry claim held by owner to leaseUntil and returns the ids still held.
+func (r *DeliveryRepository) RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error) {
+	const query = `
+		UPDATE deliveries
+		SET next_attempt_at = $2
+		WHERE claimed_by = $1 AND status = 'retrying'
+		RETURNING id
+	`
+	rows, err := r.db.QueryContext(ctx, query, owner, leaseUntil.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Requeue schedules a failed delivery, and the other failed deliveries of the same digest, to be retried at.
+func (r *DeliveryRepository) Requeue(ctx context.Context, id int64, at time.Time) error {
+	const query = `
+		UPDATE deliveries d
+		SET status = 'retrying',
+		    next_attempt_at = $2,
+		    updated_at = $2
+		FROM deliveries target
+		WHERE target.id = $1 AND target.status = 'failed' AND d.status = 'failed'
+		  AND (d.id = target.id OR (target.kind = 'digest' AND d.kind = 'digest'
+		       AND d.email = target.email AND d.scheduled_for = target.scheduled_for))
+	`
+	res, err := r.db.ExecContext(ctx, query, id, at.UTC())
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns one page of deliveries, most recent first, and the total number of matches.
+func (r *DeliveryRepository) List(ctx context.Context, filter model.DeliveryFilter) ([]*model.Delivery, int, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.SubscriptionID != "" {
+		where("subscription_id = $%d", filter.SubscriptionID)
+	}
+	if filter.Email != "" {
+		where("lower(email) = lower($%d)", filter.Email)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM deliveries ` + whereClause
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	pageQuery := fmt.Sprintf(`
+		SELECT `+deliveryColumns+`
+		FROM deliveries
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, pageQuery, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*model.Delivery, error) {
+	defer rows.Close()
+
+	var items []*model.Delivery
+	for rows.Next() {
+		var (
+			d        model.Delivery
+			response sql.NullString
+			failure  sql.NullString
+		)
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Email, &d.City, &d.Kind, &d.ScheduledFor, &d.Status,
+			&d.Attempts, &response, &failure, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt, &d.SentAt); err != nil {
+			return nil, err
+		}
+		d.ProviderResponse = response.String
+		d.Failure = failure.String
+		items = append(items, &d)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SchedulerRepository struct {
+	db *sql.DB
+}
+
+func NewSchedulerRepository(db *sql.DB) repository.SchedulerRepository {
+	return &SchedulerRepository{db: db}
+}
+
+// ListSchedules returns a page of confirmed subscriptions in the order they fall due, each with its latest
+// delivery and the number of deliveries that failed or are being retried since the last one sent.
+func (r *SchedulerRepository) ListSchedules(ctx context.Context, filter model.ScheduleFilter) ([]*model.Schedule, int, error) {
+	var (
+		conditions = []string{"s.confirmed = TRUE"}
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.SubscriptionID != "" {
+		where("s.id = $%d", filter.SubscriptionID)
+	}
+	if filter.Email != "" {
+		where("lower(s.email) = lower($%d)", filter.Email)
+	}
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM weather_subscriptions s ` + whereClause
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	pageQuery := fmt.Sprintf(`
+		SELECT s.id, s.email, s.city, s.frequency, s.digest, s.next_run_at, s.paused_until,
+		       s.claimed_by, s.lease_expires_at, last.created_at, last.status, failures.n
+		FROM weather_subscriptions s
+		LEFT JOIN LATERAL (
+			SELECT d.created_at, d.status
+			FROM deliveries d
+			WHERE d.subscription_id = s.id
+			ORDER BY d.created_at DESC, d.id DESC
+			LIMIT 1
+		) last ON TRUE
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS n
+			FROM deliveries d
+			WHERE d.subscription_id = s.id AND d.status IN ('failed', 'retrying')
+			  AND d.created_at > COALESCE((
+				SELECT MAX(sent.created_at) FROM deliveries sent
+				WHERE sent.subscription_id = s.id AND sent.status = 'sent'
+			  ), '-infinity')
+		) failures
+		%s
+		ORDER BY s.next_run_at NULLS FIRST, s.id
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, pageQuery, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []*model.Schedule
+	for rows.Next() {
+		var (
+			s                    model.Schedule
+			leasedBy, lastStatus sql.NullString
+		)
+		if err := rows.Scan(&s.SubscriptionID, &s.Email, &s.City, &s.Frequency, &s.Digest, &s.NextRunAt, &s.PausedUntil,
+			&leasedBy, &s.LeaseExpiresAt, &s.LastRunAt, &lastStatus, &s.ConsecutiveFailures); err != nil {
+			return nil, 0, err
+		}
+		s.LeasedBy = leasedBy.String
+		s.LastOutcome = lastStatus.String
+		items = append(items, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *SchedulerRepository) GetState(ctx context.Context) (*model.SchedulerState, error) {
+	const query = `SELECT paused, paused_at, pause_reason, updated_at FROM scheduler_state`
+	return scanSchedulerState(r.db.QueryRowContext(ctx, query))
+}
+
+// SetPaused pauses or resumes every scheduler instance and returns the new state. Pausing an already
+// paused scheduler keeps the time it was first paused.
+func (r *SchedulerRepository) SetPaused(ctx context.Context, paused bool, reason string, at time.Time) (*model.SchedulerState, error) {
+	const query = `
+		UPDATE scheduler_state
+		SET paused = $1,
+		    paused_at = CASE WHEN NOT $1 THEN NULL WHEN paused THEN paused_at ELSE $3 END,
+		    pause_reason = CASE WHEN $1 THEN NULLIF($2, '') END,
+		    updated_at = $3
+		RETURNING paused, paused_at, pause_reason, updated_at
+	`
+	return scanSchedulerState(r.db.QueryRowContext(ctx, query, paused, reason, at.UTC()))
+}
+
+func scanSchedulerState(row rowScanner) (*model.SchedulerState, error) {
+	var (
+		state  model.SchedulerState
+		reason sql.NullString
+	)
+	if err := row.Scan(&state.Paused, &state.PausedAt, &reason, &state.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	state.PauseReason = reason.String
+	return &state, nil
+}
@@ -5,53 +5,170 @@ import (
 	"Weather-API-Application/internal/repository"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 type SubscriptionRepository struct {
 	db *sql.DB
 }
 
-var ErrNotFound = errors.New("subscription not found")
+var (
+	ErrNotFound     = repository.ErrNotFound
+	ErrLimitReached = repository.ErrLimitReached
+)
+
+// subscriptionColumns lists the columns read by scanSubscription, in scan order.
+const subscriptionColumns = `id, email, city, frequency, confirmed, digest, created_at, confirmed_at, paused_until,
+		quiet_start, quiet_end, quiet_timezone, quiet_catch_up, next_run_at, quiet_backlog`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (*model.Subscription, error) {
+	var (
+		s                                   model.Subscription
+		quietStart, quietEnd, quietTimezone sql.NullString
+		quietCatchUp                        bool
+		quietBacklog                        []byte
+	)
+	err := row.Scan(&s.ID, &s.Email, &s.City, &s.Frequency, &s.Confirmed, &s.Digest, &s.CreatedAt, &s.ConfirmedAt, &s.PausedUntil,
+		&quietStart, &quietEnd, &quietTimezone, &quietCatchUp, &s.NextRunAt, &quietBacklog)
+	if err != nil {
+		return nil, err
+	}
+	if quietBacklog != nil {
+		if err := json.Unmarshal(quietBacklog, &s.QuietBacklog); err != nil {
+			return nil, fmt.Errorf("decode quiet backlog: %w", err)
+		}
+	}
+	if quietStart.Valid && quietEnd.Valid && quietTimezone.Valid {
+		s.QuietHours = &model.QuietHours{
+			Start:          quietStart.String,
+			End:            quietEnd.String,
+			Timezone:       quietTimezone.String,
+			CatchUpSummary: quietCatchUp,
+		}
+	}
+	return &s, nil
+}
+
+// quietHoursArgs converts optional quiet hours into nullable query arguments.
+func quietHoursArgs(q *model.QuietHours) (start, end, timezone any, catchUp bool) {
+	if q == nil {
+		return nil, nil, nil, false
+	}
+	return q.Start, q.End, q.Timezone, q.CatchUpSummary
+}
 
 func NewSubscriptionRepository(db *sql.DB) repository.SubscriptionRepository {
 	return &SubscriptionRepository{db: db}
 }
 
-func (r *SubscriptionRepository) CheckConfirmation(ctx context.Context, subscriptionRequest *model.Subscription) (rowExists bool, confirmed bool, err error) {
-	const query = `
-		SELECT confirmed
+// Create inserts a pending subscription unless the email already holds limit active subscriptions, i.e. confirmed
+// ones and pending ones created at or after pendingSince, and returns ErrLimitReached in that case. The count and the
+// insert share a transaction holding an advisory lock on the email, so concurrent requests cannot both pass the cap.
+// Both treat addresses differing only in case as the same email.
+func (r *SubscriptionRepository) Create(ctx context.Context, s *model.Subscription, limit int, pendingSince time.Time) error {
+	const lockQuery = `
+		SELECT pg_advisory_xact_lock(hashtext(LOWER($1)))
+	`
+	const countQuery = `
+		SELECT COUNT(*)
 		FROM weather_subscriptions
-		WHERE email = $1 AND city = $2
+		WHERE LOWER(email) = LOWER($1) AND (confirmed = TRUE OR created_at >= $2)
+	`
+	const insertQuery = `
+		INSERT INTO weather_subscriptions (email, city, confirm_token, frequency, digest, confirmed, created_at,
+		                                   quiet_start, quiet_end, quiet_timezone, quiet_catch_up)
+		VALUES ($1,   $2,   $3,            $4,       $5,     FALSE,     NOW(),
+		        $6,          $7,        $8,             $9)
 	`
-	row := r.db.QueryRowContext(ctx, query, subscriptionRequest.Email, subscriptionRequest.City)
-	err = row.Scan(&confirmed)
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, false, nil
-	}
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return false, false, err
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockQuery, s.Email); err != nil {
+		return err
 	}
-	return true, confirmed, nil
+	var active int
+	if err := tx.QueryRowContext(ctx, countQuery, s.Email, pendingSince.UTC()).Scan(&active); err != nil {
+		return err
+	}
+	if active >= limit {
+		return ErrLimitReached
+	}
+
+	quietStart, quietEnd, quietTimezone, quietCatchUp := quietHoursArgs(s.QuietHours)
+	if _, err := tx.ExecContext(ctx, insertQuery, s.Email, s.City, s.ConfirmToken, s.Frequency, s.Digest,
+		quietStart, quietEnd, quietTimezone, quietCatchUp); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *SubscriptionRepository) Create(ctx context.Context, s *model.Subscription) error {
+// CreateBatch inserts the subscriptions in one transaction, skipping those whose email and city already exist.
+// Inserted subscriptions get their ID and CreatedAt set; the result reports which ones were inserted.
+// Pending subscriptions are stored with their confirmation token.
+func (r *SubscriptionRepository) CreateBatch(ctx context.Context, subs []*model.Subscription) ([]bool, error) {
 	const query = `
-		INSERT INTO weather_subscriptions (email, city, token, frequency, confirmed, created_at)
-		VALUES ($1,   $2,   $3,    $4,       FALSE,     NOW())
+		INSERT INTO weather_subscriptions (email, city, frequency, digest, confirmed, confirmed_at,
+		                                   confirm_token, created_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $5::boolean THEN NOW() END, $6, NOW())
+		ON CONFLICT (email, city) DO NOTHING
+		RETURNING id, created_at
 	`
-	_, err := r.db.ExecContext(ctx, query, s.Email, s.City, s.Token, s.Frequency)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	nullable := func(v string) sql.NullString {
+		return sql.NullString{String: v, Valid: v != ""}
+	}
+	inserted := make([]bool, len(subs))
+	for i, s := range subs {
+		err := stmt.QueryRowContext(ctx, s.Email, s.City, s.Frequency, s.Digest, s.Confirmed,
+			nullable(s.ConfirmToken)).Scan(&s.ID, &s.CreatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inserted[i] = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
 }
 
-func (r *SubscriptionRepository) UpdateTokenByEmailCity(ctx context.Context, s *model.Subscription) error {
+func (r *SubscriptionRepository) UpdateConfirmTokenByEmailCity(ctx context.Context, s *model.Subscription) error {
 	const query = `
 		UPDATE weather_subscriptions
-		SET token = $1, confirmed = FALSE, created_at = NOW()
+		SET confirm_token = $1, digest = $4, confirmed = FALSE, created_at = NOW(),
+		    quiet_start = $5, quiet_end = $6, quiet_timezone = $7, quiet_catch_up = $8
 		WHERE email = $2 AND city = $3
 	`
-	res, err := r.db.ExecContext(ctx, query, s.Token, s.Email, s.City)
+	quietStart, quietEnd, quietTimezone, quietCatchUp := quietHoursArgs(s.QuietHours)
+	res, err := r.db.ExecContext(ctx, query, s.ConfirmToken, s.Email, s.City, s.Digest,
+		quietStart, quietEnd, quietTimezone, quietCatchUp)
 	if err != nil {
 		return err
 	}
@@ -63,11 +180,13 @@ func (r *SubscriptionRepository) UpdateTokenByEmailCity(ctx context.Context, s *
 	return nil
 }
 
-func (r *SubscriptionRepository) GetByToken(ctx context.Context, token string) (string, *model.Subscription, error) {
+// GetByConfirmToken finds a pending subscription by its confirmation token. Confirmation retires the token,
+// so a link that was already used is not found.
+func (r *SubscriptionRepository) GetByConfirmToken(ctx context.Context, token string) (string, *model.Subscription, error) {
 	const query = `
-		SELECT id, email, city, frequency, confirmed
+		SELECT id, email, city, frequency, confirmed, digest, created_at
 		FROM weather_subscriptions
-		WHERE token = $1
+		WHERE confirm_token = $1
 	`
 	var (
 		id        string
@@ -75,8 +194,10 @@ func (r *SubscriptionRepository) GetByToken(ctx context.Context, token string) (
 		city      string
 		frequency string
 		confirmed bool
+		digest    bool
+		createdAt time.Time
 	)
-	err := r.db.QueryRowContext(ctx, query, token).Scan(&id, &email, &city, &frequency, &confirmed)
+	err := r.db.QueryRowContext(ctx, query, token).Scan(&id, &email, &city, &frequency, &confirmed, &digest, &createdAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return "", nil, ErrNotFound
 	}
@@ -85,18 +206,22 @@ func (r *SubscriptionRepository) GetByToken(ctx context.Context, token string) (
 	}
 
 	return id, &model.Subscription{
-		Email:     email,
-		City:      city,
-		Frequency: frequency,
-		Token:     token,
-		Confirmed: confirmed,
+		ID:           id,
+		Email:        email,
+		City:         city,
+		Frequency:    frequency,
+		ConfirmToken: token,
+		Confirmed:    confirmed,
+		Digest:       digest,
+		CreatedAt:    createdAt,
 	}, nil
 }
 
+// SetConfirmed marks the subscription confirmed and retires its confirmation token.
 func (r *SubscriptionRepository) SetConfirmed(ctx context.Context, subId string) error {
 	const query = `
 		UPDATE weather_subscriptions
-		SET confirmed = TRUE, confirmed_at = NOW()
+		SET confirmed = TRUE, confirmed_at = NOW(), confirm_token = NULL
 		WHERE id = $1
 	`
 	res, err := r.db.ExecContext(ctx, query, subId)
@@ -110,12 +235,228 @@ func (r *SubscriptionRepository) SetConfirmed(ctx context.Context, subId string)
 	return nil
 }
 
-func (r *SubscriptionRepository) DeleteByToken(ctx context.Context, token string) error {
+func (r *SubscriptionRepository) GetByID(ctx context.Context, subId string) (*model.Subscription, error) {
+	const query = `
+		SELECT ` + subscriptionColumns + `
+		FROM weather_subscriptions
+		WHERE id = $1
+	`
+	s, err := scanSubscription(r.db.QueryRowContext(ctx, query, subId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetPausedUntil suspends deliveries until the given time; nil resumes the subscription.
+func (r *SubscriptionRepository) SetPausedUntil(ctx context.Context, subId string, until *time.Time) error {
+	const query = `
+		UPDATE weather_subscriptions
+		SET paused_until = $2
+		WHERE id = $1
+	`
+	var pausedUntil any
+	if until != nil {
+		pausedUntil = until.UTC()
+	}
+	res, err := r.db.ExecContext(ctx, query, subId, pausedUntil)
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetQuietHours replaces the quiet hours of a subscription; nil removes them.
+func (r *SubscriptionRepository) SetQuietHours(ctx context.Context, subId string, quietHours *model.QuietHours) error {
+	const query = `
+		UPDATE weather_subscriptions
+		SET quiet_start = $2, quiet_end = $3, quiet_timezone = $4, quiet_catch_up = $5
+		WHERE id = $1
+	`
+	quietStart, quietEnd, quietTimezone, quietCatchUp := quietHoursArgs(quietHours)
+	res, err := r.db.ExecContext(ctx, query, subId, quietStart, quietEnd, quietTimezone, quietCatchUp)
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetQuietBacklog stores the slots skipped during quiet hours; nil clears them.
+func (r *SubscriptionRepository) SetQuietBacklog(ctx context.Context, subId string, backlog *model.QuietBacklog) error {
+	const query = `
+		UPDATE weather_subscriptions
+		SET quiet_backlog = $2
+		WHERE id = $1
+	`
+	var value any
+	if backlog != nil {
+		encoded, err := json.Marshal(backlog)
+		if err != nil {
+			return err
+		}
+		value = string(encoded)
+	}
+	res, err := r.db.ExecContext(ctx, query, subId, value)
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetNextRunAt schedules the next delivery slot of a subscription.
+func (r *SubscriptionRepository) SetNextRunAt(ctx context.Context, subId string, at time.Time) error {
+	const query = `
+		UPDATE weather_subscriptions
+		SET next_run_at = $2
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, subId, at.UTC())
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDue leases up to limit confirmed subscriptions that are due at now or were never scheduled to owner
+// until leaseUntil, and returns them. Rows leased by another owner are skipped until the lease expires, and
+// rows locked by a concurrent claim are skipped outright, so no two dispatchers hold the same subscription.
+// Due digest subscriptions sharing an email with a claimed digest subscription are claimed along with it,
+// even beyond limit, so a digest is not split. A claim ends with CompleteRun or when the lease runs out.
+func (r *SubscriptionRepository) ClaimDue(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Subscription, error) {
+	const claimQuery = `
+		SELECT ` + subscriptionColumns + `
+		FROM weather_subscriptions
+		WHERE confirmed = TRUE AND (next_run_at IS NULL OR next_run_at <= $1)
+		  AND (lease_expires_at IS NULL OR lease_expires_at < $1)
+		ORDER BY next_run_at NULLS FIRST, id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	const digestQuery = `
+		SELECT ` + subscriptionColumns + `
+		FROM weather_subscriptions
+		WHERE confirmed = TRUE AND digest = TRUE AND next_run_at <= $1 AND email = ANY($2)
+		  AND (lease_expires_at IS NULL OR lease_expires_at < $1)
+		FOR UPDATE SKIP LOCKED
+	`
+	const leaseQuery = `
+		UPDATE weather_subscriptions
+		SET claimed_by = $2, lease_expires_at = $3
+		WHERE id = $1
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	claimed, err := querySubscriptions(ctx, tx, claimQuery, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(claimed))
+	var digestEmails []string
+	for _, s := range claimed {
+		seen[s.ID] = true
+		if s.Digest && s.NextRunAt != nil {
+			digestEmails = append(digestEmails, s.Email)
+		}
+	}
+	if len(digestEmails) > 0 {
+		siblings, err := querySubscriptions(ctx, tx, digestQuery, now.UTC(), digestEmails)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range siblings {
+			if !seen[s.ID] {
+				seen[s.ID] = true
+				claimed = append(claimed, s)
+			}
+		}
+	}
+
+	for _, s := range claimed {
+		if _, err := tx.ExecContext(ctx, leaseQuery, s.ID, owner, leaseUntil.UTC()); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// RenewLeases extends every lease held by owner to leaseUntil and returns the ids still held.
+func (r *SubscriptionRepository) RenewLeases(ctx context.Context, owner string, leaseUntil time.Time) ([]string, error) {
+	const query = `
+		UPDATE weather_subscriptions
+		SET lease_expires_at = $2
+		WHERE claimed_by = $1
+		RETURNING id
+	`
+	rows, err := r.db.QueryContext(ctx, query, owner, leaseUntil.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CompleteRun moves a subscription leased by owner to its next slot and releases the lease.
+// It returns ErrNotFound if owner no longer holds the lease, e.g. because it expired and was taken over.
+func (r *SubscriptionRepository) CompleteRun(ctx context.Context, owner, subId string, next time.Time) error {
+	const query = `
+		UPDATE weather_subscriptions
+		SET next_run_at = $3, claimed_by = NULL, lease_expires_at = NULL
+		WHERE id = $1 AND claimed_by = $2
+	`
+	res, err := r.db.ExecContext(ctx, query, subId, owner, next.UTC())
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) DeleteByID(ctx context.Context, subId string) error {
 	const query = `
 		DELETE FROM weather_subscriptions
-		WHERE token = $1
+		WHERE id = $1
 	`
-	res, err := r.db.ExecContext(ctx, query, token)
+	res, err := r.db.ExecContext(ctx, query, subId)
 	if err != nil {
 		return err
 	}
@@ -126,14 +467,108 @@ func (r *SubscriptionRepository) DeleteByToken(ctx context.Context, token string
 	return nil
 }
 
-func (r *SubscriptionRepository) ListConfirmed(ctx context.Context) ([]*model.Subscription, error) {
+// ListByEmail returns all subscriptions of an email, pending ones included, matching it case-insensitively.
+func (r *SubscriptionRepository) ListByEmail(ctx context.Context, email string) ([]*model.Subscription, error) {
+	const query = `
+		SELECT ` + subscriptionColumns + `
+		FROM weather_subscriptions
+		WHERE LOWER(email) = LOWER($1)
+		ORDER BY city
+	`
+	return r.listSubscriptions(ctx, query, email)
+}
+
+// List returns one page of subscriptions matching the filter, newest first, and the total number of matches.
+// Email and city are matched case-insensitively.
+func (r *SubscriptionRepository) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, int, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Email != "" {
+		where("lower(email) = lower($%d)", filter.Email)
+	}
+	if filter.City != "" {
+		where("lower(city) = lower($%d)", filter.City)
+	}
+	if filter.Frequency != "" {
+		where("frequency = $%d", filter.Frequency)
+	}
+	if filter.Confirmed != nil {
+		where("confirmed = $%d", *filter.Confirmed)
+	}
+	if filter.CreatedFrom != nil {
+		where("created_at >= $%d", filter.CreatedFrom.UTC())
+	}
+	if filter.CreatedTo != nil {
+		where("created_at < $%d", filter.CreatedTo.UTC())
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM weather_subscriptions ` + whereClause
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	pageQuery := fmt.Sprintf(`
+		SELECT `+subscriptionColumns+`
+		FROM weather_subscriptions
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	subs, err := r.listSubscriptions(ctx, pageQuery, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return subs, total, nil
+}
+
+// ForEach streams every subscription, oldest first, to fn without loading the table into memory.
+// Iteration stops at the first error returned by fn.
+func (r *SubscriptionRepository) ForEach(ctx context.Context, fn func(*model.Subscription) error) error {
 	const query = `
-		SELECT email, city, frequency, token, confirmed
+		SELECT ` + subscriptionColumns + `
 		FROM weather_subscriptions
-		WHERE confirmed = TRUE
-		ORDER BY email, city
+		ORDER BY id
 	`
 	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *SubscriptionRepository) listSubscriptions(ctx context.Context, query string, args ...any) ([]*model.Subscription, error) {
+	return querySubscriptions(ctx, r.db, query, args...)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func querySubscriptions(ctx context.Context, q queryer, query string, args ...any) ([]*model.Subscription, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,8 +576,8 @@ func (r *SubscriptionRepository) ListConfirmed(ctx context.Context) ([]*model.Su
 
 	var subs []*model.Subscription
 	for rows.Next() {
-		s := new(model.Subscription)
-		if err := rows.Scan(&s.Email, &s.City, &s.Frequency, &s.Token, &s.Confirmed); err != nil {
+		s, err := scanSubscription(rows)
+		if err != nil {
 			return nil, err
 		}
 		subs = append(subs, s)
@@ -152,3 +587,151 @@ func (r *SubscriptionRepository) ListConfirmed(ctx context.Context) ([]*model.Su
 	}
 	return subs, nil
 }
+
+// SetDigestByEmail applies the digest preference to all confirmed subscriptions of an email
+// and returns how many rows changed mode.
+func (r *SubscriptionRepository) SetDigestByEmail(ctx context.Context, email string, digest bool) (int64, error) {
+	const query = `
+		UPDATE weather_subscriptions
+		SET digest = $2
+		WHERE email = $1 AND confirmed = TRUE AND digest <> $2
+	`
+	res, err := r.db.ExecContext(ctx, query, email, digest)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *SubscriptionRepository) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	const query = `
+		DELETE FROM weather_subscriptions
+		WHERE email = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, email)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// EraseByEmail permanently deletes everything tied to the email, i.e. its subscriptions with their delivery
+// log, its captured emails and its suppression entry, and stores the audit entry in the same transaction.
+// The address is matched case-insensitively everywhere. The number of removed subscriptions is recorded on audit.
+func (r *SubscriptionRepository) EraseByEmail(ctx context.Context, email string, audit *model.ErasureAudit) error {
+	const deleteQuery = `
+		DELETE FROM weather_subscriptions
+		WHERE LOWER(email) = LOWER($1)
+	`
+	const capturesQuery = `
+		DELETE FROM captured_emails
+		WHERE LOWER(recipient) = LOWER($1)
+	`
+	// Suppressions are keyed by the normalized address
+	const suppressionsQuery = `
+		DELETE FROM email_suppressions
+		WHERE email = LOWER(TRIM($1))
+	`
+	const auditQuery = `
+		INSERT INTO erasure_audit (email_hash, subscriptions_removed, erased_at)
+		VALUES ($1, $2, $3)
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, deleteQuery, email)
+	if err != nil {
+		return err
+	}
+	if audit.SubscriptionsRemoved, err = res.RowsAffected(); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, capturesQuery, email); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, suppressionsQuery, email); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, auditQuery, audit.EmailHash, audit.SubscriptionsRemoved, audit.ErasedAt.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeletePendingCreatedBefore removes unconfirmed subscriptions whose token was issued before cutoff.
+func (r *SubscriptionRepository) DeletePendingCreatedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	const query = `
+		DELETE FROM weather_subscriptions
+		WHERE confirmed = FALSE AND created_at < $1
+	`
+	res, err := r.db.ExecContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// HashLegacyTokens replaces raw tokens left over from before hashing was introduced with their hashes.
+func (r *SubscriptionRepository) HashLegacyTokens(ctx context.Context, hash func(string) string) (int64, error) {
+	const selectQuery = `
+		SELECT id, confirm_token
+		FROM weather_subscriptions
+		WHERE token_hashed = FALSE
+		FOR UPDATE
+	`
+	const updateQuery = `
+		UPDATE weather_subscriptions
+		SET confirm_token = $2, token_hashed = TRUE
+		WHERE id = $1
+	`
+	type legacyRow struct {
+		id           string
+		confirmToken sql.NullString
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return 0, err
+	}
+	var legacy []legacyRow
+	for rows.Next() {
+		var row legacyRow
+		if err := rows.Scan(&row.id, &row.confirmToken); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		legacy = append(legacy, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	hashNullable := func(v sql.NullString) sql.NullString {
+		if !v.Valid {
+			return v
+		}
+		return sql.NullString{String: hash(v.String), Valid: true}
+	}
+	for _, row := range legacy {
+		if _, err := tx.ExecContext(ctx, updateQuery, row.id, hashNullable(row.confirmToken)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(legacy)), nil
+}
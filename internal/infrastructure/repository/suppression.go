@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type SuppressionRepository struct {
+	db *sql.DB
+}
+
+func NewSuppressionRepository(db *sql.DB) repository.SuppressionRepository {
+	return &SuppressionRepository{db: db}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (r *SuppressionRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	const query = `
+		SELECT EXISTS (
+			SELECT 1 FROM email_suppressions
+			WHERE email = $1 AND suppressed_at IS NOT NULL
+		)
+	`
+	var suppressed bool
+	err := r.db.QueryRowContext(ctx, query, normalizeEmail(email)).Scan(&suppressed)
+	return suppressed, err
+}
+
+// RecordFailure counts a delivery failure and returns the number of failures in a row. The count
+// starts over when the previous failure happened before windowStart.
+func (r *SuppressionRepository) RecordFailure(ctx context.Context, email string, at time.Time, windowStart time.Time) (int, error) {
+	const query = `
+		INSERT INTO email_suppressions (email, failure_count, last_event_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (email) DO UPDATE
+		SET failure_count = CASE
+		        WHEN email_suppressions.last_event_at < $3 THEN 1
+		        ELSE email_suppressions.failure_count + 1
+		    END,
+		    last_event_at = GREATEST(email_suppressions.last_event_at, EXCLUDED.last_event_at)
+		RETURNING failure_count
+	`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, normalizeEmail(email), at.UTC(), windowStart.UTC()).Scan(&count)
+	return count, err
+}
+
+// Suppress blocks the address. An existing suppression keeps its original time and reason.
+func (r *SuppressionRepository) Suppress(ctx context.Context, email, reason string, at time.Time) error {
+	const query = `
+		INSERT INTO email_suppressions (email, last_event_at, suppressed_at, reason)
+		VALUES ($1, $2, $2, $3)
+		ON CONFLICT (email) DO UPDATE
+		SET last_event_at = GREATEST(email_suppressions.last_event_at, EXCLUDED.last_event_at),
+		    suppressed_at = COALESCE(email_suppressions.suppressed_at, EXCLUDED.suppressed_at),
+		    reason = COALESCE(email_suppressions.reason, EXCLUDED.reason)
+	`
+	_, err := r.db.ExecContext(ctx, query, normalizeEmail(email), at.UTC(), reason)
+	return err
+}
+
+// List returns one page of suppressed addresses, most recent first, and their total number.
+func (r *SuppressionRepository) List(ctx context.Context, limit, offset int) ([]*model.Suppression, int, error) {
+	const countQuery = `
+		SELECT COUNT(*) FROM email_suppressions
+		WHERE suppressed_at IS NOT NULL
+	`
+	const pageQuery = `
+		SELECT email, reason, failure_count, suppressed_at, last_event_at
+		FROM email_suppressions
+		WHERE suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC, email
+		LIMIT $1 OFFSET $2
+	`
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, pageQuery, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []*model.Suppression
+	for rows.Next() {
+		var s model.Suppression
+		if err := rows.Scan(&s.Email, &s.Reason, &s.FailureCount, &s.SuppressedAt, &s.LastEventAt); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// Lift removes a suppression together with the address's failure history.
+func (r *SuppressionRepository) Lift(ctx context.Context, email string) error {
+	const query = `
+		DELETE FROM email_suppressions
+		WHERE email = $1 AND suppressed_at IS NOT NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
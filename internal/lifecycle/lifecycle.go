@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"Weather-API-Application/internal/logger"
+)
+
+// Manager shuts the application down in a fixed order once it is asked to stop: typically background
+// work first, then the HTTP server, then the resources both of them use. Each step gets its own deadline,
+// and a step that fails or overruns does not keep the later ones from running.
+type Manager struct {
+	steps []step
+}
+
+type step struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Add registers a shutdown step. Steps run in the order they were added; stop is given a context that
+// expires after timeout and should return once that happens, though it is abandoned if it does not.
+func (m *Manager) Add(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	m.steps = append(m.steps, step{name: name, timeout: timeout, stop: stop})
+}
+
+// Closer adapts a resource such as *sql.DB to a shutdown step.
+func Closer(c io.Closer) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return c.Close()
+	}
+}
+
+// Shutdown runs every step in order and returns the errors of those that failed or overran.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	logger.Info(ctx, "Shutting down", slog.Int("steps", len(m.steps)))
+
+	var errs []error
+	for _, s := range m.steps {
+		start := time.Now()
+		if err := s.run(ctx); err != nil {
+			logger.Error(ctx, err, slog.String("step", s.name))
+			errs = append(errs, err)
+			continue
+		}
+		logger.Info(ctx, "Shutdown step done",
+			slog.String("step", s.name),
+			slog.Duration("took", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
+
+func (s step) run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to stop %s: %w", s.name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s did not stop within %s: %w", s.name, s.timeout, ctx.Err())
+	}
+}
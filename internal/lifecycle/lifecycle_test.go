@@ -0,0 +1,59 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestShutdown(t *testing.T) {
+	var order []string
+	record := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	m := NewManager()
+	m.Add("scheduler", time.Second, record("scheduler", nil))
+	m.Add("http server", time.Second, record("http server", assert.AnError))
+	m.Add("database", time.Second, Closer(closerFunc(func() error {
+		order = append(order, "database")
+		return nil
+	})))
+
+	err := m.Shutdown(context.Background())
+	require.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "failed to stop http server")
+	assert.Equal(t, []string{"scheduler", "http server", "database"}, order,
+		"Steps run in the order they were added, also after one of them failed")
+}
+
+func TestShutdownAbandonsOverrunningStep(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	closed := false
+
+	m := NewManager()
+	m.Add("scheduler", 20*time.Millisecond, func(ctx context.Context) error {
+		<-release // ignores its deadline
+		return nil
+	})
+	m.Add("database", time.Second, func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "scheduler did not stop within 20ms")
+	assert.True(t, closed, "Later steps still run once a step overruns its deadline")
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"Weather-API-Application/internal/utils/response"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth requires HTTP Basic credentials matching user and password.
+// Both sides are hashed before comparing so the check takes the same time whatever the input length.
+func AdminAuth(user, password string) gin.HandlerFunc {
+	wantUser := sha256.Sum256([]byte(user))
+	wantPassword := sha256.Sum256([]byte(password))
+
+	return func(ctx *gin.Context) {
+		gotUser, gotPassword, ok := ctx.Request.BasicAuth()
+		userHash := sha256.Sum256([]byte(gotUser))
+		passwordHash := sha256.Sum256([]byte(gotPassword))
+
+		userMatch := subtle.ConstantTimeCompare(userHash[:], wantUser[:])
+		passwordMatch := subtle.ConstantTimeCompare(passwordHash[:], wantPassword[:])
+		if !ok || userMatch&passwordMatch != 1 {
+			ctx.Header("WWW-Authenticate", `Basic realm="admin"`)
+			response.WriteErrorJSON(ctx, http.StatusUnauthorized,
+				fmt.Errorf("admin authentication failed from %s", ctx.ClientIP()),
+				"Unauthorized")
+			return
+		}
+		ctx.Next()
+	}
+}
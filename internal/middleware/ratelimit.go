@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"Weather-API-Application/internal/utils/ratelimit"
+	"Weather-API-Application/internal/utils/response"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit rejects requests from client IPs that exceed the limiter's budget with 429 and Retry-After.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ip := ctx.ClientIP()
+		if ok, retryAfter := limiter.Allow(ip); !ok {
+			response.WriteTooManyRequests(ctx,
+				fmt.Errorf("rate limit exceeded for %s", ip),
+				retryAfter,
+				"Too many requests, please try again later")
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// SubscriptionFilter narrows an admin listing of subscriptions. Zero values do not filter.
+type SubscriptionFilter struct {
+	Email       string
+	City        string
+	Frequency   string
+	Confirmed   *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
+
+// AdminSubscription is the view of a subscription returned to support staff, including the
+// identifiers and timestamps hidden from subscribers.
+type AdminSubscription struct {
+	ID          string      `json:"id" example:"42"`
+	Email       string      `json:"email"`
+	City        string      `json:"city"`
+	Frequency   string      `json:"frequency"`
+	Confirmed   bool        `json:"confirmed"`
+	Digest      bool        `json:"digest"`
+	QuietHours  *QuietHours `json:"quiet_hours,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ConfirmedAt *time.Time  `json:"confirmed_at,omitempty"`
+	PausedUntil *time.Time  `json:"paused_until,omitempty"`
+	NextRunAt   *time.Time  `json:"next_run_at,omitempty"`
+}
+
+// AdminSubscriptionPage is one page of an admin listing together with the total number of matches.
+type AdminSubscriptionPage struct {
+	Items  []AdminSubscription `json:"items"`
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+func NewAdminSubscription(s *Subscription) AdminSubscription {
+	return AdminSubscription{
+		ID:          s.ID,
+		Email:       s.Email,
+		City:        s.City,
+		Frequency:   s.Frequency,
+		Confirmed:   s.Confirmed,
+		Digest:      s.Digest,
+		QuietHours:  s.QuietHours,
+		CreatedAt:   s.CreatedAt,
+		ConfirmedAt: s.ConfirmedAt,
+		PausedUntil: s.PausedUntil,
+		NextRunAt:   s.NextRunAt,
+	}
+}
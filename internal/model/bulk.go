@@ -0,0 +1,24 @@
+package model
+
+// ImportRow is one subscriber read from a bulk import file.
+type ImportRow struct {
+	Email     string `json:"email"`
+	City      string `json:"city"`
+	Frequency string `json:"frequency"`
+	Digest    bool   `json:"digest"`
+}
+
+// ImportReport summarises a bulk import. Line numbers refer to the uploaded file, header included.
+type ImportReport struct {
+	Total               int              `json:"total"`
+	Imported            int              `json:"imported"`
+	Failed              int              `json:"failed"`
+	ConfirmationsQueued int              `json:"confirmations_queued"`
+	Errors              []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// CapturedEmail is an email a dry run kept from being sent, rendered exactly as it would have been sent.
+type CapturedEmail struct {
+	ID         int64             `json:"id" example:"42"`
+	To         string            `json:"to" example:"user@example.com"`
+	Subject    string            `json:"subject" example:"Kyiv forecast"`
+	HTML       string            `json:"html"`
+	Text       string            `json:"text"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	CapturedAt time.Time         `json:"captured_at"`
+}
+
+// CapturedEmailFilter narrows an admin listing of captured emails. Zero values do not filter.
+type CapturedEmailFilter struct {
+	Email  string
+	Limit  int
+	Offset int
+}
+
+// CapturedEmailPage is one page of captured emails together with the total number of matches.
+type CapturedEmailPage struct {
+	Items  []*CapturedEmail `json:"items"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
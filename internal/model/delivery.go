@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Delivery statuses. A delivery is queued when its email is about to be sent and ends up in one of the others.
+// Retrying deliveries are sent again once their backoff has elapsed; failed ones form the dead-letter queue.
+// Captured deliveries went through a dry run, which kept their email from being sent. Skipped deliveries
+// were retries no longer wanted when they came due, such as for a subscription paused meanwhile.
+const (
+	DeliveryQueued     = "queued"
+	DeliverySent       = "sent"
+	DeliveryCaptured   = "captured"
+	DeliveryRetrying   = "retrying"
+	DeliveryFailed     = "failed"
+	DeliverySuppressed = "suppressed"
+	DeliverySkipped    = "skipped"
+)
+
+// Classes of delivery failures. Permanent failures, such as a rejected address or an unknown city,
+// are not retried.
+const (
+	FailurePermanent = "permanent"
+	FailureTransient = "transient"
+)
+
+// Kinds of delivered emails.
+const (
+	DeliveryKindUpdate   = "update"
+	DeliveryKindCatchUp  = "catch-up"
+	DeliveryKindDigest   = "digest"
+	DeliveryKindOnDemand = "on-demand"
+)
+
+// Delivery records one email sent for a subscription in one slot. A digest is recorded once per city it covers.
+type Delivery struct {
+	ID               int64      `json:"id" example:"42"`
+	SubscriptionID   string     `json:"subscription_id" example:"7"`
+	Email            string     `json:"email"`
+	City             string     `json:"city"`
+	Kind             string     `json:"kind" example:"update" enums:"update,catch-up,digest,on-demand"`
+	ScheduledFor     time.Time  `json:"scheduled_for"`
+	Status           string     `json:"status" example:"sent" enums:"queued,sent,captured,retrying,failed,suppressed,skipped"`
+	Attempts         int        `json:"attempts"`
+	ProviderResponse string     `json:"provider_response,omitempty" example:"550 5.1.1 user unknown"`
+	Failure          string     `json:"failure,omitempty" example:"permanent" enums:"permanent,transient"`
+	NextAttemptAt    *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	SentAt           *time.Time `json:"sent_at,omitempty"`
+}
+
+// DeliveryOutcome is the result of one attempt at sending a delivery.
+type DeliveryOutcome struct {
+	Status           string
+	ProviderResponse string
+	Failure          string
+	NextAttemptAt    *time.Time
+	At               time.Time
+}
+
+// DeliveryFilter narrows an admin listing of deliveries. Zero values do not filter.
+type DeliveryFilter struct {
+	SubscriptionID string
+	Email          string
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+// DeliveryPage is one page of the delivery log together with the total number of matches.
+type DeliveryPage struct {
+	Items  []*Delivery `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
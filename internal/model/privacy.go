@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// DataExport is everything held about an email, returned to the data subject on request.
+type DataExport struct {
+	Email         string                     `json:"email"`
+	GeneratedAt   time.Time                  `json:"generated_at"`
+	Subscriptions []SubscriptionExportRecord `json:"subscriptions"`
+	Deliveries    []DeliveryExportRecord     `json:"deliveries"`
+}
+
+// SubscriptionExportRecord is one subscription as it appears in a data export. Tokens are left out
+// because only their hashes are stored.
+type SubscriptionExportRecord struct {
+	City        string      `json:"city"`
+	Frequency   string      `json:"frequency"`
+	Confirmed   bool        `json:"confirmed"`
+	Digest      bool        `json:"digest"`
+	QuietHours  *QuietHours `json:"quiet_hours,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ConfirmedAt *time.Time  `json:"confirmed_at,omitempty"`
+	PausedUntil *time.Time  `json:"paused_until,omitempty"`
+}
+
+// DeliveryExportRecord is one entry of the delivery log as it appears in a data export.
+type DeliveryExportRecord struct {
+	City             string     `json:"city"`
+	Kind             string     `json:"kind"`
+	ScheduledFor     time.Time  `json:"scheduled_for"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	ProviderResponse string     `json:"provider_response,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	SentAt           *time.Time `json:"sent_at,omitempty"`
+}
+
+// ErasureAudit records that an erasure request was fulfilled without keeping the email itself.
+// EmailHash is a keyed hash, so a later request can be matched against it but not reversed.
+type ErasureAudit struct {
+	EmailHash            string
+	SubscriptionsRemoved int64
+	ErasedAt             time.Time
+}
+
+// PrivacyRequest asks for a verification link to export or erase the data held about an email.
+type PrivacyRequest struct {
+	Email  string `json:"email" example:"user@example.com"`
+	Action string `json:"action" example:"export" enums:"export,erase"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Schedule is the delivery schedule of a confirmed subscription as the scheduler sees it, with the
+// outcome of its latest delivery.
+type Schedule struct {
+	SubscriptionID      string     `json:"subscription_id" example:"7"`
+	Email               string     `json:"email"`
+	City                string     `json:"city"`
+	Frequency           string     `json:"frequency" example:"hourly" enums:"hourly,daily"`
+	Digest              bool       `json:"digest"`
+	NextRunAt           *time.Time `json:"next_run_at,omitempty"`
+	PausedUntil         *time.Time `json:"paused_until,omitempty"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty"`
+	LastOutcome         string     `json:"last_outcome,omitempty" example:"sent" enums:"queued,sent,retrying,failed,suppressed"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LeasedBy            string     `json:"leased_by,omitempty" example:"api-7f9c-1a2b3c4d"`
+	LeaseExpiresAt      *time.Time `json:"lease_expires_at,omitempty"`
+}
+
+// ScheduleFilter narrows an admin listing of schedules. Zero values do not filter.
+type ScheduleFilter struct {
+	SubscriptionID string
+	Email          string
+	Limit          int
+	Offset         int
+}
+
+// SchedulePage is one page of schedules together with the total number of matches.
+type SchedulePage struct {
+	Items  []*Schedule `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// SchedulerState is shared by all scheduler instances. While paused, none of them sends scheduled
+// updates or retries; slots that fall due meanwhile are treated as missed once it resumes.
+type SchedulerState struct {
+	Paused      bool       `json:"paused"`
+	PausedAt    *time.Time `json:"paused_at,omitempty"`
+	PauseReason string     `json:"pause_reason,omitempty" example:"SMTP provider incident"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// SchedulerStatus is the shared scheduler state as reported by the instance that answered.
+type SchedulerStatus struct {
+	SchedulerState
+	Instance string `json:"instance" example:"api-7f9c-1a2b3c4d"`
+}
+
+// PauseSchedulerRequest optionally says why the scheduler is paused.
+type PauseSchedulerRequest struct {
+	Reason string `json:"reason" example:"SMTP provider incident"`
+}
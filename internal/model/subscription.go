@@ -1,9 +1,71 @@
 package model
 
+import "time"
+
 type Subscription struct {
-	Email     string `json:"email"`
-	City      string `json:"city"`
-	Frequency string `json:"frequency"`
-	Token     string `json:"token"`
-	Confirmed bool   `json:"confirmed"`
+	ID           string      `json:"-"`
+	Email        string      `json:"email"`
+	City         string      `json:"city"`
+	Frequency    string      `json:"frequency"`
+	ConfirmToken string      `json:"-"`
+	Confirmed    bool        `json:"confirmed"`
+	Digest       bool        `json:"digest"`
+	QuietHours   *QuietHours `json:"quiet_hours,omitempty"`
+	CreatedAt    time.Time   `json:"-"`
+	ConfirmedAt  *time.Time  `json:"-"`
+	PausedUntil  *time.Time  `json:"-"`
+	// NextRunAt is the next delivery slot; nil until the scheduler has scheduled the subscription.
+	NextRunAt    *time.Time    `json:"-"`
+	QuietBacklog *QuietBacklog `json:"-"`
+}
+
+// QuietBacklog is what an hourly subscription missed during quiet hours, kept until its catch-up summary is sent.
+type QuietBacklog struct {
+	Skipped  int                   `json:"skipped"`
+	Observed []*WeatherAPIResponse `json:"observed,omitempty"`
+}
+
+// IsPaused reports whether deliveries are suspended at the given moment.
+func (s *Subscription) IsPaused(now time.Time) bool {
+	return s.PausedUntil != nil && now.Before(*s.PausedUntil)
+}
+
+// IsQuiet reports whether the given moment falls into the subscription's quiet hours.
+func (s *Subscription) IsQuiet(now time.Time) bool {
+	return s.QuietHours != nil && s.QuietHours.Contains(now)
+}
+
+// QuietHours is a daily window, in the subscriber's timezone, during which hourly deliveries are skipped.
+// Start and End use the "15:04" layout; a window with End before Start spans midnight.
+type QuietHours struct {
+	Start          string `json:"start" example:"22:00"`
+	End            string `json:"end" example:"07:00"`
+	Timezone       string `json:"timezone" example:"Europe/Kyiv"`
+	CatchUpSummary bool   `json:"catch_up_summary"`
+}
+
+// Contains reports whether t falls into the window. Invalid settings never match.
+func (q *QuietHours) Contains(t time.Time) bool {
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return false
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
 }
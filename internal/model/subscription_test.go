@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		quiet    QuietHours
+		at       time.Time
+		expected bool
+		reason   string
+	}{
+		{
+			name:     "Inside window spanning midnight",
+			quiet:    QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Kyiv"},
+			at:       time.Date(2026, 3, 10, 3, 0, 0, 0, kyiv),
+			expected: true,
+			reason:   "3 a.m. is inside 22:00-07:00",
+		},
+		{
+			name:     "End of window is exclusive",
+			quiet:    QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Kyiv"},
+			at:       time.Date(2026, 3, 10, 7, 0, 0, 0, kyiv),
+			expected: false,
+			reason:   "Deliveries resume exactly at the end of the window",
+		},
+		{
+			name:     "Outside window spanning midnight",
+			quiet:    QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Kyiv"},
+			at:       time.Date(2026, 3, 10, 12, 0, 0, 0, kyiv),
+			expected: false,
+			reason:   "Noon is outside 22:00-07:00",
+		},
+		{
+			name:     "Inside same-day window",
+			quiet:    QuietHours{Start: "13:00", End: "15:30", Timezone: "Europe/Kyiv"},
+			at:       time.Date(2026, 3, 10, 15, 29, 0, 0, kyiv),
+			expected: true,
+			reason:   "15:29 is inside 13:00-15:30",
+		},
+		{
+			name:     "Window is evaluated in the subscriber's timezone",
+			quiet:    QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Kyiv"},
+			at:       time.Date(2026, 7, 10, 1, 0, 0, 0, time.UTC),
+			expected: true,
+			reason:   "01:00 UTC is 04:00 in Kyiv during summer time",
+		},
+		{
+			name:     "Unknown timezone never matches",
+			quiet:    QuietHours{Start: "00:00", End: "23:59", Timezone: "Mars/Base"},
+			at:       time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+			expected: false,
+			reason:   "Invalid settings must not silence deliveries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.quiet.Contains(tt.at), tt.reason)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Email event types accepted by the bounce and complaint webhook.
+const (
+	EventHardBounce = "hard_bounce"
+	EventSoftBounce = "soft_bounce"
+	EventComplaint  = "complaint"
+)
+
+// Suppression reasons.
+const (
+	SuppressedHardBounce       = "hard_bounce"
+	SuppressedComplaint        = "complaint"
+	SuppressedRepeatedFailures = "repeated_failures"
+)
+
+// Suppression is an address no email may be sent to until an admin lifts it.
+type Suppression struct {
+	Email        string    `json:"email"`
+	Reason       string    `json:"reason" example:"hard_bounce"`
+	FailureCount int       `json:"failure_count"`
+	SuppressedAt time.Time `json:"suppressed_at"`
+	LastEventAt  time.Time `json:"last_event_at"`
+}
+
+// SuppressionPage is one page of the suppression list together with the total number of suppressed addresses.
+type SuppressionPage struct {
+	Items  []*Suppression `json:"items"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// EmailEvent is a delivery problem reported by the mail provider.
+type EmailEvent struct {
+	Type       string    `json:"type" example:"hard_bounce" enums:"hard_bounce,soft_bounce,complaint"`
+	Email      string    `json:"email" example:"user@example.com"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Detail     string    `json:"detail,omitempty" example:"550 5.1.1 user unknown"`
+}
+
+// EmailEventBatch is the body of a webhook call.
+type EmailEventBatch struct {
+	Events []EmailEvent `json:"events"`
+}
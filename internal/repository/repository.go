@@ -3,14 +3,71 @@ package repository
 import (
 	"Weather-API-Application/internal/model"
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrNotFound is returned by repository implementations when no row matches the lookup.
+var ErrNotFound = errors.New("subscription not found")
+
+// ErrLimitReached is returned by SubscriptionRepository.Create when the email already holds the allowed number
+// of active subscriptions.
+var ErrLimitReached = errors.New("subscription limit reached")
+
 type SubscriptionRepository interface {
-	CheckConfirmation(ctx context.Context, subscriptionRequest *model.Subscription) (rowExists bool, confirmed bool, err error)
-	Create(ctx context.Context, subscriptionRequest *model.Subscription) error
-	UpdateTokenByEmailCity(ctx context.Context, subscriptionRequest *model.Subscription) error
-	GetByToken(ctx context.Context, token string) (string, *model.Subscription, error)
+	Create(ctx context.Context, subscriptionRequest *model.Subscription, limit int, pendingSince time.Time) error
+	CreateBatch(ctx context.Context, subs []*model.Subscription) ([]bool, error)
+	UpdateConfirmTokenByEmailCity(ctx context.Context, subscriptionRequest *model.Subscription) error
+	GetByConfirmToken(ctx context.Context, token string) (string, *model.Subscription, error)
 	SetConfirmed(ctx context.Context, subId string) error
-	DeleteByToken(ctx context.Context, token string) error
-	ListConfirmed(ctx context.Context) ([]*model.Subscription, error)
+	GetByID(ctx context.Context, subId string) (*model.Subscription, error)
+	SetPausedUntil(ctx context.Context, subId string, until *time.Time) error
+	SetQuietHours(ctx context.Context, subId string, quietHours *model.QuietHours) error
+	SetQuietBacklog(ctx context.Context, subId string, backlog *model.QuietBacklog) error
+	SetNextRunAt(ctx context.Context, subId string, at time.Time) error
+	ClaimDue(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Subscription, error)
+	RenewLeases(ctx context.Context, owner string, leaseUntil time.Time) ([]string, error)
+	CompleteRun(ctx context.Context, owner, subId string, next time.Time) error
+	DeleteByID(ctx context.Context, subId string) error
+	ListByEmail(ctx context.Context, email string) ([]*model.Subscription, error)
+	List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, int, error)
+	ForEach(ctx context.Context, fn func(*model.Subscription) error) error
+	SetDigestByEmail(ctx context.Context, email string, digest bool) (int64, error)
+	DeleteByEmail(ctx context.Context, email string) (int64, error)
+	EraseByEmail(ctx context.Context, email string, audit *model.ErasureAudit) error
+	DeletePendingCreatedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	HashLegacyTokens(ctx context.Context, hash func(string) string) (int64, error)
+}
+
+// SuppressionRepository stores addresses that must not receive email. Emails are matched case-insensitively.
+type SuppressionRepository interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+	RecordFailure(ctx context.Context, email string, at time.Time, windowStart time.Time) (int, error)
+	Suppress(ctx context.Context, email, reason string, at time.Time) error
+	List(ctx context.Context, limit, offset int) ([]*model.Suppression, int, error)
+	Lift(ctx context.Context, email string) error
+}
+
+// DeliveryRepository stores the log of delivered emails, including the retries of failed ones.
+type DeliveryRepository interface {
+	Queue(ctx context.Context, deliveries []*model.Delivery, at time.Time) error
+	Finish(ctx context.Context, ids []int64, outcome model.DeliveryOutcome) error
+	ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error)
+	RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error)
+	Requeue(ctx context.Context, id int64, at time.Time) error
+	List(ctx context.Context, filter model.DeliveryFilter) ([]*model.Delivery, int, error)
+}
+
+// CaptureRepository keeps the emails captured in dry-run mode. Recipients are matched case-insensitively.
+type CaptureRepository interface {
+	Save(ctx context.Context, email *model.CapturedEmail) error
+	List(ctx context.Context, filter model.CapturedEmailFilter) ([]*model.CapturedEmail, int, error)
+	DeleteAll(ctx context.Context) (int64, error)
+}
+
+// SchedulerRepository reports on delivery schedules and keeps the state shared by all scheduler instances.
+type SchedulerRepository interface {
+	ListSchedules(ctx context.Context, filter model.ScheduleFilter) ([]*model.Schedule, int, error)
+	GetState(ctx context.Context) (*model.SchedulerState, error)
+	SetPaused(ctx context.Context, paused bool, reason string, at time.Time) (*model.SchedulerState, error)
 }
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"Weather-API-Application/internal/model"
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSubscriptionRepository is a Testify mock implementing SubscriptionRepository
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionRepository) Create(ctx context.Context, subscriptionRequest *model.Subscription, limit int, pendingSince time.Time) error {
+	args := m.Called(ctx, subscriptionRequest, limit, pendingSince)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) CreateBatch(ctx context.Context, subs []*model.Subscription) ([]bool, error) {
+	args := m.Called(ctx, subs)
+
+	var inserted []bool
+	if v := args.Get(0); v != nil {
+		inserted = v.([]bool)
+	}
+	return inserted, args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) UpdateConfirmTokenByEmailCity(ctx context.Context, subscriptionRequest *model.Subscription) error {
+	args := m.Called(ctx, subscriptionRequest)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) GetByConfirmToken(ctx context.Context, token string) (string, *model.Subscription, error) {
+	args := m.Called(ctx, token)
+
+	var sub *model.Subscription
+	if v := args.Get(1); v != nil {
+		sub = v.(*model.Subscription)
+	}
+	return args.String(0), sub, args.Error(2)
+}
+
+func (m *MockSubscriptionRepository) SetConfirmed(ctx context.Context, subId string) error {
+	args := m.Called(ctx, subId)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) GetByID(ctx context.Context, subId string) (*model.Subscription, error) {
+	args := m.Called(ctx, subId)
+
+	var sub *model.Subscription
+	if v := args.Get(0); v != nil {
+		sub = v.(*model.Subscription)
+	}
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) SetPausedUntil(ctx context.Context, subId string, until *time.Time) error {
+	args := m.Called(ctx, subId, until)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) SetQuietHours(ctx context.Context, subId string, quietHours *model.QuietHours) error {
+	args := m.Called(ctx, subId, quietHours)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) SetQuietBacklog(ctx context.Context, subId string, backlog *model.QuietBacklog) error {
+	args := m.Called(ctx, subId, backlog)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) SetNextRunAt(ctx context.Context, subId string, at time.Time) error {
+	args := m.Called(ctx, subId, at)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) ClaimDue(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Subscription, error) {
+	args := m.Called(ctx, owner, now, leaseUntil, limit)
+
+	var subs []*model.Subscription
+	if v := args.Get(0); v != nil {
+		subs = v.([]*model.Subscription)
+	}
+	return subs, args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) RenewLeases(ctx context.Context, owner string, leaseUntil time.Time) ([]string, error) {
+	args := m.Called(ctx, owner, leaseUntil)
+
+	var ids []string
+	if v := args.Get(0); v != nil {
+		ids = v.([]string)
+	}
+	return ids, args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) CompleteRun(ctx context.Context, owner, subId string, next time.Time) error {
+	args := m.Called(ctx, owner, subId, next)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) DeleteByID(ctx context.Context, subId string) error {
+	args := m.Called(ctx, subId)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) ListByEmail(ctx context.Context, email string) ([]*model.Subscription, error) {
+	args := m.Called(ctx, email)
+
+	var subs []*model.Subscription
+	if v := args.Get(0); v != nil {
+		subs = v.([]*model.Subscription)
+	}
+	return subs, args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, int, error) {
+	args := m.Called(ctx, filter)
+
+	var subs []*model.Subscription
+	if v := args.Get(0); v != nil {
+		subs = v.([]*model.Subscription)
+	}
+	return subs, args.Int(1), args.Error(2)
+}
+
+func (m *MockSubscriptionRepository) ForEach(ctx context.Context, fn func(*model.Subscription) error) error {
+	args := m.Called(ctx, fn)
+	if v := args.Get(0); v != nil {
+		for _, sub := range v.([]*model.Subscription) {
+			if err := fn(sub); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) SetDigestByEmail(ctx context.Context, email string, digest bool) (int64, error) {
+	args := m.Called(ctx, email, digest)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) EraseByEmail(ctx context.Context, email string, audit *model.ErasureAudit) error {
+	args := m.Called(ctx, email, audit)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) DeletePendingCreatedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) HashLegacyTokens(ctx context.Context, hash func(string) string) (int64, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockSuppressionRepository is a Testify mock implementing SuppressionRepository
+type MockSuppressionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSuppressionRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSuppressionRepository) RecordFailure(ctx context.Context, email string, at time.Time, windowStart time.Time) (int, error) {
+	args := m.Called(ctx, email, at, windowStart)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSuppressionRepository) Suppress(ctx context.Context, email, reason string, at time.Time) error {
+	args := m.Called(ctx, email, reason, at)
+	return args.Error(0)
+}
+
+func (m *MockSuppressionRepository) List(ctx context.Context, limit, offset int) ([]*model.Suppression, int, error) {
+	args := m.Called(ctx, limit, offset)
+
+	var items []*model.Suppression
+	if v := args.Get(0); v != nil {
+		items = v.([]*model.Suppression)
+	}
+	return items, args.Int(1), args.Error(2)
+}
+
+func (m *MockSuppressionRepository) Lift(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+// MockDeliveryRepository is a Testify mock implementing DeliveryRepository
+type MockDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeliveryRepository) Queue(ctx context.Context, deliveries []*model.Delivery, at time.Time) error {
+	args := m.Called(ctx, deliveries, at)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) Finish(ctx context.Context, ids []int64, outcome model.DeliveryOutcome) error {
+	args := m.Called(ctx, ids, outcome)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error) {
+	args := m.Called(ctx, owner, now, leaseUntil, limit)
+
+	var items []*model.Delivery
+	if v := args.Get(0); v != nil {
+		items = v.([]*model.Delivery)
+	}
+	return items, args.Error(1)
+}
+
+func (m *MockDeliveryRepository) RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error) {
+	args := m.Called(ctx, owner, leaseUntil)
+
+	var ids []int64
+	if v := args.Get(0); v != nil {
+		ids = v.([]int64)
+	}
+	return ids, args.Error(1)
+}
+
+func (m *MockDeliveryRepository) Requeue(ctx context.Context, id int64, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) List(ctx context.Context, filter model.DeliveryFilter) ([]*model.Delivery, int, error) {
+	args := m.Called(ctx, filter)
+
+	var items []*model.Delivery
+	if v := args.Get(0); v != nil {
+		items = v.([]*model.Delivery)
+	}
+	return items, args.Int(1), args.Error(2)
+}
+
+// MockSchedulerRepository is a Testify mock implementing SchedulerRepository
+type MockSchedulerRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchedulerRepository) ListSchedules(ctx context.Context, filter model.ScheduleFilter) ([]*model.Schedule, int, error) {
+	args := m.Called(ctx, filter)
+
+	var items []*model.Schedule
+	if v := args.Get(0); v != nil {
+		items = v.([]*model.Schedule)
+	}
+	return items, args.Int(1), args.Error(2)
+}
+
+func (m *MockSchedulerRepository) GetState(ctx context.Context) (*model.SchedulerState, error) {
+	args := m.Called(ctx)
+
+	var state *model.SchedulerState
+	if v := args.Get(0); v != nil {
+		state = v.(*model.SchedulerState)
+	}
+	return state, args.Error(1)
+}
+
+func (m *MockSchedulerRepository) SetPaused(ctx context.Context, paused bool, reason string, at time.Time) (*model.SchedulerState, error) {
+	args := m.Called(ctx, paused, reason, at)
+
+	var state *model.SchedulerState
+	if v := args.Get(0); v != nil {
+		state = v.(*model.SchedulerState)
+	}
+	return state, args.Error(1)
+}
+
+// MockCaptureRepository is a Testify mock implementing CaptureRepository
+type MockCaptureRepository struct {
+	mock.Mock
+}
+
+func (m *MockCaptureRepository) Save(ctx context.Context, email *model.CapturedEmail) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockCaptureRepository) List(ctx context.Context, filter model.CapturedEmailFilter) ([]*model.CapturedEmail, int, error) {
+	args := m.Called(ctx, filter)
+
+	var items []*model.CapturedEmail
+	if v := args.Get(0); v != nil {
+		items = v.([]*model.CapturedEmail)
+	}
+	return items, args.Int(1), args.Error(2)
+}
+
+func (m *MockCaptureRepository) DeleteAll(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -6,12 +6,10 @@ import (
 	"Weather-API-Application/internal/middleware"
 	"Weather-API-Application/internal/utils/response"
 	"context"
+	"expvar"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -21,10 +19,15 @@ import (
 type Server struct {
 	Router *gin.Engine
 	cfg    *config.Config
+	http   *http.Server
 }
 
 func NewServer(cfg *config.Config) *Server {
 	router := gin.New()
+	// Client IPs (used for rate limiting) are only taken from forwarding headers set by trusted proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal(context.Background(), fmt.Errorf("invalid TRUSTED_PROXIES: %w", err))
+	}
 	router.Use(middleware.Logger())
 	router.Use(gin.Recovery())
 
@@ -35,6 +38,12 @@ func NewServer(cfg *config.Config) *Server {
 	// Swagger UI handler
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Runtime and dispatcher metrics as JSON; they include the command line, so like the admin API
+	// they are only served to admins, and not at all while no admin credentials are set
+	if cfg.AdminEnabled() {
+		router.GET("/debug/vars", middleware.AdminAuth(cfg.AdminUser, cfg.AdminPassword), gin.WrapH(expvar.Handler()))
+	}
+
 	// 404 handler to log and return a consistent error body
 	router.NoRoute(func(c *gin.Context) {
 		response.WriteErrorJSON(c, http.StatusNotFound, fmt.Errorf("route not found: %s %s", c.Request.Method, c.Request.URL.Path), "Route not found")
@@ -43,38 +52,29 @@ func NewServer(cfg *config.Config) *Server {
 	return &Server{
 		cfg:    cfg,
 		Router: router,
+		http: &http.Server{
+			Addr:    cfg.AppPort,
+			Handler: router,
+		},
 	}
 }
 
-func (s *Server) Run(ctx context.Context) {
-
-	server := &http.Server{
-		Addr:    s.cfg.AppPort,
-		Handler: s.Router,
-	}
-
+// Start serves HTTP in the background until Shutdown is called. Failing to listen is fatal.
+func (s *Server) Start(ctx context.Context) {
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info(ctx, "Server listening", slog.String("addr", s.http.Addr))
+		if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatal(ctx, fmt.Errorf("listen: %s\n", err))
 		}
 	}()
-
-	waitForSignal(ctx, server)
 }
 
-func waitForSignal(ctx context.Context, srv *http.Server) {
-
-	quit := make(chan os.Signal, 1)
-
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logger.Info(ctx, "Shutdown Server ...")
-
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal(ctx, fmt.Errorf("Server forced to shutdown: %w", err))
+// Shutdown stops accepting connections and waits for in-flight requests until ctx is done,
+// after which the remaining connections are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
-
-	logger.Info(ctx, "Server exiting")
+	return nil
 }
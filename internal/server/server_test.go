@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Weather-API-Application/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugVars(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		cfg        *config.Config
+		auth       bool
+		wantStatus int
+	}{
+		{
+			name:       "Not served without admin credentials",
+			cfg:        &config.Config{},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Requires admin credentials",
+			cfg:        &config.Config{AdminUser: "admin", AdminPassword: "secret"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Served to admins",
+			cfg:        &config.Config{AdminUser: "admin", AdminPassword: "secret"},
+			auth:       true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.auth {
+				req.SetBasicAuth("admin", "secret")
+			}
+			w := httptest.NewRecorder()
+			NewServer(tt.cfg).Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"memstats"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package bulk_service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
+	"Weather-API-Application/internal/utils/validate"
+)
+
+// Import modes: rows are either stored as confirmed right away or stored pending
+// and sent the usual confirmation email.
+const (
+	ModeConfirmed        = "confirmed"
+	ModeSendConfirmation = "send-confirmation"
+)
+
+// insertChunkSize bounds how many rows are inserted per transaction.
+const insertChunkSize = 500
+
+type Scheduler interface {
+	StartFor(ctx context.Context, sub *model.Subscription)
+}
+
+// BulkService imports and exports subscriptions in bulk for admins.
+type BulkService struct {
+	repo        repository.SubscriptionRepository
+	emailClient client.Client
+	cfg         *config.Config
+	scheduler   Scheduler
+	tokens      *token.Hasher
+
+	// confirmations tracks the goroutines emailing import confirmations; closing stopping makes them
+	// return after the batch in flight.
+	confirmations sync.WaitGroup
+	mu            sync.Mutex
+	stopping      chan struct{}
+	draining      bool
+}
+
+func NewBulkService(repo repository.SubscriptionRepository, emailClient client.Client, cfg *config.Config) *BulkService {
+	return &BulkService{
+		repo:        repo,
+		emailClient: emailClient,
+		cfg:         cfg,
+		tokens:      token.NewHasher(cfg.TokenSecret),
+		stopping:    make(chan struct{}),
+	}
+}
+
+func (s *BulkService) WithScheduler(scheduler Scheduler) *BulkService {
+	s.scheduler = scheduler
+	return s
+}
+
+// pendingRow is a valid row waiting to be inserted, with the raw confirmation token to email if any.
+type pendingRow struct {
+	line         int
+	sub          *model.Subscription
+	confirmToken string
+}
+
+// Import validates every row and stores the valid ones, skipping subscriptions that already exist.
+// Invalid rows are reported and do not stop the import; an unreadable file or one with more than
+// IMPORT_MAX_ROWS rows is rejected before anything is stored. In ModeSendConfirmation the
+// confirmation emails are sent in the background, in throttled batches.
+func (s *BulkService) Import(ctx context.Context, r io.Reader, format, mode string) (*model.ImportReport, error) {
+	if mode != ModeConfirmed && mode != ModeSendConfirmation {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMode, mode)
+	}
+
+	report := &model.ImportReport{Errors: []model.ImportRowError{}}
+	var (
+		rows []pendingRow
+		seen = make(map[string]int)
+	)
+	err := decodeRows(r, format, func(line int, row model.ImportRow, rowErr error) error {
+		report.Total++
+		if report.Total > s.cfg.ImportMaxRows {
+			return fmt.Errorf("%w: at most %d rows per import", ErrTooManyRows, s.cfg.ImportMaxRows)
+		}
+
+		row.Email = strings.TrimSpace(row.Email)
+		row.City = strings.TrimSpace(row.City)
+		row.Frequency = strings.ToLower(strings.TrimSpace(row.Frequency))
+		if rowErr == nil {
+			rowErr = validateRow(row)
+		}
+		key := row.Email + "|" + strings.ToLower(row.City)
+		if firstLine, ok := seen[key]; rowErr == nil && ok {
+			rowErr = fmt.Errorf("duplicate of line %d", firstLine)
+		}
+		if rowErr != nil {
+			report.Errors = append(report.Errors, model.ImportRowError{Line: line, Email: row.Email, Error: rowErr.Error()})
+			return nil
+		}
+		seen[key] = line
+
+		rows = append(rows, s.newPendingRow(line, row, mode))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var toConfirm []pendingRow
+	for start := 0; start < len(rows); start += insertChunkSize {
+		chunk := rows[start:min(start+insertChunkSize, len(rows))]
+		subs := make([]*model.Subscription, len(chunk))
+		for i, row := range chunk {
+			subs[i] = row.sub
+		}
+
+		inserted, err := s.repo.CreateBatch(ctx, subs)
+		if err != nil {
+			logger.Error(ctx, fmt.Errorf("failed to import subscriptions: %w", err),
+				slog.Int("first_line", chunk[0].line))
+			for _, row := range chunk {
+				report.Errors = append(report.Errors, model.ImportRowError{Line: row.line, Email: row.sub.Email, Error: "failed to store subscription"})
+			}
+			continue
+		}
+
+		for i, row := range chunk {
+			if !inserted[i] {
+				report.Errors = append(report.Errors, model.ImportRowError{Line: row.line, Email: row.sub.Email, Error: "subscription already exists"})
+				continue
+			}
+			report.Imported++
+			if mode == ModeConfirmed {
+				if s.scheduler != nil {
+					s.scheduler.StartFor(ctx, row.sub)
+				}
+			} else {
+				toConfirm = append(toConfirm, row)
+			}
+		}
+	}
+	report.Failed = len(report.Errors)
+
+	if len(toConfirm) > 0 {
+		// The import request returns before the batches are through, so they must not be tied to it
+		if s.startConfirmations(context.WithoutCancel(ctx), toConfirm) {
+			report.ConfirmationsQueued = len(toConfirm)
+		} else {
+			logger.Info(ctx, "Import confirmation emails not sent, shutting down",
+				slog.Int("remaining", len(toConfirm)))
+		}
+	}
+
+	logger.Info(ctx, "Subscriptions imported",
+		slog.String("format", format),
+		slog.String("mode", mode),
+		slog.Int("total", report.Total),
+		slog.Int("imported", report.Imported),
+		slog.Int("failed", report.Failed))
+	return report, nil
+}
+
+func validateRow(row model.ImportRow) error {
+	switch {
+	case !validate.IsValidEmail(row.Email):
+		return fmt.Errorf("invalid email format")
+	case !validate.IsValidCity(row.City):
+		return fmt.Errorf("city is required")
+	case !validate.IsValidFrequency(row.Frequency):
+		return fmt.Errorf("frequency must be 'hourly' or 'daily'")
+	}
+	return nil
+}
+
+func (s *BulkService) newPendingRow(line int, row model.ImportRow, mode string) pendingRow {
+	sub := &model.Subscription{
+		Email:     row.Email,
+		City:      row.City,
+		Frequency: row.Frequency,
+		Digest:    row.Digest,
+	}
+	pending := pendingRow{line: line, sub: sub}
+
+	if mode == ModeConfirmed {
+		sub.Confirmed = true
+	} else {
+		pending.confirmToken = token.New()
+		sub.ConfirmToken = s.tokens.Hash(pending.confirmToken)
+	}
+	return pending
+}
+
+// startConfirmations sends the confirmation emails of imported rows in the background, unless the service
+// is draining, and reports whether it started.
+func (s *BulkService) startConfirmations(ctx context.Context, rows []pendingRow) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.confirmations.Add(1)
+	go func() {
+		defer s.confirmations.Done()
+		s.sendConfirmations(ctx, rows)
+	}()
+	return true
+}
+
+// Drain stops the import confirmation emails still being sent once their batch in flight is through, and
+// waits for that until ctx is done. Subscriptions left without a confirmation email stay pending until
+// the janitor removes them, or until the subscriber subscribes again.
+func (s *BulkService) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.draining {
+		s.draining = true
+		close(s.stopping)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.confirmations.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("import confirmation emails not finished: %w", ctx.Err())
+	}
+}
+
+// sendConfirmations emails confirmation links in batches of IMPORT_CONFIRM_BATCH_SIZE,
+// waiting IMPORT_CONFIRM_BATCH_INTERVAL between batches to stay within SMTP provider limits.
+func (s *BulkService) sendConfirmations(ctx context.Context, rows []pendingRow) {
+	var sent, failed int
+	for start := 0; start < len(rows); start += s.cfg.ImportConfirmBatchSize {
+		if start > 0 {
+			select {
+			case <-ctx.Done():
+				logger.Info(ctx, "Import confirmation emails cancelled",
+					slog.Int("sent", sent),
+					slog.Int("remaining", len(rows)-start))
+				return
+			case <-s.stopping:
+				logger.Info(ctx, "Import confirmation emails stopped, shutting down",
+					slog.Int("sent", sent),
+					slog.Int("remaining", len(rows)-start))
+				return
+			case <-time.After(s.cfg.ImportConfirmBatchInterval):
+			}
+		}
+
+		for _, row := range rows[start:min(start+s.cfg.ImportConfirmBatchSize, len(rows))] {
+			confirmURL := config.BuildConfirmURL(s.cfg.BaseURL, row.confirmToken)
+			if err := client.SendConfirmation(ctx, row.sub.Email, confirmURL, s.emailClient); err != nil {
+				failed++
+				logger.Error(ctx, err,
+					slog.String("email", row.sub.Email),
+					slog.String("city", row.sub.City))
+				continue
+			}
+			sent++
+		}
+	}
+
+	logger.Info(ctx, "Import confirmation emails sent",
+		slog.Int("sent", sent),
+		slog.Int("failed", failed))
+}
+
+// Export streams all subscriptions to w in the given format.
+func (s *BulkService) Export(ctx context.Context, w io.Writer, format string) error {
+	enc, err := newExportWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	if err := s.repo.ForEach(ctx, func(sub *model.Subscription) error {
+		count++
+		return enc.Write(sub)
+	}); err != nil {
+		return fmt.Errorf("failed to export subscriptions: %w", err)
+	}
+	if err := enc.Flush(); err != nil {
+		return fmt.Errorf("failed to export subscriptions: %w", err)
+	}
+
+	logger.Info(ctx, "Subscriptions exported",
+		slog.String("format", format),
+		slog.Int("count", count))
+	return nil
+}
+
+// IsSupportedFormat reports whether format can be imported and exported.
+func IsSupportedFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON
+}
@@ -0,0 +1,234 @@
+package bulk_service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailClient struct {
+	mu   sync.Mutex
+	sent []string
+	done chan struct{}
+	want int
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, to)
+	if len(c.sent) == c.want {
+		close(c.done)
+	}
+	return nil
+}
+
+type mockScheduler struct {
+	started []*model.Subscription
+}
+
+func (m *mockScheduler) StartFor(ctx context.Context, sub *model.Subscription) {
+	m.started = append(m.started, sub)
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		TokenSecret:            "secret",
+		BaseURL:                "http://localhost",
+		ImportMaxRows:          100,
+		ImportConfirmBatchSize: 2,
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	cfg := newTestConfig()
+	csvFile := strings.Join([]string{
+		"Email,City,Frequency,Digest,Ignored",
+		"a@example.com,Kyiv,Daily,false,x",
+		"not-an-email,Kyiv,daily,,",
+		"b@example.com,Lviv,weekly,,",
+		"a@example.com,kyiv,hourly,,",
+		"c@example.com,Odesa,hourly,true,",
+		"d@example.com,Dnipro,daily,maybe,",
+		"e@example.com,Kharkiv,daily,,",
+	}, "\n")
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 3 && subs[0].Frequency == "daily" && subs[1].Digest && subs[0].Confirmed && subs[0].ConfirmToken == ""
+	})).Return([]bool{true, true, false}, nil)
+	sch := &mockScheduler{}
+
+	svc := NewBulkService(repo, nil, cfg).WithScheduler(sch)
+	report, err := svc.Import(context.Background(), strings.NewReader(csvFile), FormatCSV, ModeConfirmed)
+	require.NoError(t, err)
+
+	require.Equal(t, 7, report.Total)
+	require.Equal(t, 2, report.Imported)
+	require.Equal(t, 5, report.Failed)
+	require.Equal(t, []model.ImportRowError{
+		{Line: 3, Email: "not-an-email", Error: "invalid email format"},
+		{Line: 4, Email: "b@example.com", Error: "frequency must be 'hourly' or 'daily'"},
+		{Line: 5, Email: "a@example.com", Error: "duplicate of line 2"},
+		{Line: 7, Email: "d@example.com", Error: "digest must be true or false"},
+		{Line: 8, Email: "e@example.com", Error: "subscription already exists"},
+	}, report.Errors)
+	require.Len(t, sch.started, 2, "Confirmed imports must be scheduled right away")
+	repo.AssertExpectations(t)
+}
+
+func TestImportNDJSONSendsConfirmations(t *testing.T) {
+	cfg := newTestConfig()
+	ndjson := `{"email":"a@example.com","city":"Kyiv","frequency":"daily"}
+{"email":"b@example.com","city":"Lviv","frequency":"hourly"}
+
+{"email":"c@example.com","city":"Odesa","frequency":"daily"}
+{broken`
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 3 && !subs[0].Confirmed && subs[0].ConfirmToken != ""
+	})).Return([]bool{true, true, true}, nil)
+	emails := &fakeEmailClient{done: make(chan struct{}), want: 3}
+	sch := &mockScheduler{}
+
+	svc := NewBulkService(repo, emails, cfg).WithScheduler(sch)
+	report, err := svc.Import(context.Background(), strings.NewReader(ndjson), FormatNDJSON, ModeSendConfirmation)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Imported)
+	require.Equal(t, 3, report.ConfirmationsQueued)
+	require.Equal(t, []model.ImportRowError{{Line: 5, Error: "invalid JSON"}}, report.Errors)
+	require.Empty(t, sch.started, "Pending imports must not be scheduled before confirmation")
+
+	select {
+	case <-emails.done:
+	case <-time.After(time.Second):
+		t.Fatal("confirmation emails were not sent")
+	}
+	require.ElementsMatch(t, []string{"a@example.com", "b@example.com", "c@example.com"}, emails.sent)
+}
+
+func TestDrainStopsImportConfirmations(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ImportConfirmBatchInterval = time.Hour
+	ndjson := `{"email":"a@example.com","city":"Kyiv","frequency":"daily"}
+{"email":"b@example.com","city":"Lviv","frequency":"hourly"}
+{"email":"c@example.com","city":"Odesa","frequency":"daily"}`
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("CreateBatch", mock.Anything, mock.Anything).Return([]bool{true, true, true}, nil)
+	emails := &fakeEmailClient{done: make(chan struct{}), want: 2}
+
+	svc := NewBulkService(repo, emails, cfg)
+	report, err := svc.Import(context.Background(), strings.NewReader(ndjson), FormatNDJSON, ModeSendConfirmation)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.ConfirmationsQueued)
+
+	select {
+	case <-emails.done:
+	case <-time.After(time.Second):
+		t.Fatal("first batch of confirmation emails was not sent")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, svc.Drain(ctx), "Drain must not wait out the interval before the next batch")
+	require.Len(t, emails.sent, 2, "The batch after the drain must not be sent")
+
+	report, err = svc.Import(context.Background(), strings.NewReader(ndjson), FormatNDJSON, ModeSendConfirmation)
+	require.NoError(t, err)
+	require.Zero(t, report.ConfirmationsQueued, "Imports during shutdown must not start sending")
+	require.Len(t, emails.sent, 2)
+}
+
+func TestImportRejectsWholeFile(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ImportMaxRows = 1
+
+	tests := []struct {
+		name        string
+		body        string
+		format      string
+		mode        string
+		expectedErr error
+	}{
+		{
+			name:        "Missing required column",
+			body:        "email,city\na@example.com,Kyiv",
+			format:      FormatCSV,
+			mode:        ModeConfirmed,
+			expectedErr: ErrMissingColumns,
+		},
+		{
+			name:        "Too many rows",
+			body:        "email,city,frequency\na@example.com,Kyiv,daily\nb@example.com,Kyiv,daily",
+			format:      FormatCSV,
+			mode:        ModeConfirmed,
+			expectedErr: ErrTooManyRows,
+		},
+		{
+			name:        "Unknown mode",
+			body:        "email,city,frequency\na@example.com,Kyiv,daily",
+			format:      FormatCSV,
+			mode:        "maybe",
+			expectedErr: ErrUnsupportedMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Nothing may be stored when the file is rejected as a whole
+			repo := new(repository.MockSubscriptionRepository)
+
+			_, err := NewBulkService(repo, nil, cfg).Import(context.Background(), strings.NewReader(tt.body), tt.format, tt.mode)
+			require.ErrorIs(t, err, tt.expectedErr)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportCSVCanBeImported(t *testing.T) {
+	cfg := newTestConfig()
+	confirmedAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	subs := []*model.Subscription{
+		{ID: "1", Email: "a@example.com", City: "Kyiv, Ukraine", Frequency: "daily", Confirmed: true, CreatedAt: confirmedAt, ConfirmedAt: &confirmedAt},
+		{ID: "2", Email: "b@example.com", City: "Lviv", Frequency: "hourly", Digest: true, CreatedAt: confirmedAt},
+		{ID: "3", Email: "+c@example.com", City: `=HYPERLINK("http://evil.example")`, Frequency: "daily", CreatedAt: confirmedAt},
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ForEach", mock.Anything, mock.Anything).Return(subs, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, NewBulkService(repo, nil, cfg).Export(context.Background(), &out, FormatCSV))
+	require.Equal(t, strings.Join([]string{
+		"id,email,city,frequency,confirmed,digest,created_at,confirmed_at,paused_until",
+		`1,a@example.com,"Kyiv, Ukraine",daily,true,false,2025-03-01T09:00:00Z,2025-03-01T09:00:00Z,`,
+		"2,b@example.com,Lviv,hourly,false,true,2025-03-01T09:00:00Z,,",
+		`3,'+c@example.com,"'=HYPERLINK(""http://evil.example"")",daily,false,false,2025-03-01T09:00:00Z,,`,
+		"",
+	}, "\n"), out.String())
+
+	var rows []model.ImportRow
+	require.NoError(t, decodeRows(&out, FormatCSV, func(line int, row model.ImportRow, err error) error {
+		require.NoError(t, err)
+		rows = append(rows, row)
+		return nil
+	}))
+	require.Equal(t, []model.ImportRow{
+		{Email: "a@example.com", City: "Kyiv, Ukraine", Frequency: "daily"},
+		{Email: "b@example.com", City: "Lviv", Frequency: "hourly", Digest: true},
+		{Email: "+c@example.com", City: `=HYPERLINK("http://evil.example")`, Frequency: "daily"},
+	}, rows)
+}
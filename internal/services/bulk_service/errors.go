@@ -0,0 +1,10 @@
+package bulk_service
+
+import "errors"
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrUnsupportedMode   = errors.New("unsupported import mode")
+	ErrMissingColumns    = errors.New("missing required columns")
+	ErrTooManyRows       = errors.New("too many rows")
+)
@@ -0,0 +1,222 @@
+package bulk_service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Weather-API-Application/internal/model"
+)
+
+// Supported file formats for import and export.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxNDJSONLine bounds a single NDJSON record so a malformed upload cannot exhaust memory.
+const maxNDJSONLine = 64 * 1024
+
+// exportColumns is the CSV header of exports. The import reads email, city, frequency and digest
+// by name and ignores the rest, so an export can be imported again.
+var exportColumns = []string{"id", "email", "city", "frequency", "confirmed", "digest", "created_at", "confirmed_at", "paused_until"}
+
+// rowFunc receives each decoded row with its line number, or the reason the line could not be decoded.
+type rowFunc func(line int, row model.ImportRow, err error) error
+
+// decodeRows reads rows in the given format. Errors returned by decodeRows itself concern the whole
+// file; problems with a single line are passed to fn.
+func decodeRows(r io.Reader, format string, fn rowFunc) error {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r, fn)
+	case FormatNDJSON:
+		return decodeNDJSON(r, fn)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func decodeCSV(r io.Reader, fn rowFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, required := range []string{"email", "city", "frequency"} {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return uncsvCell(record[i])
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, model.ImportRow{}, parseErr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := model.ImportRow{
+			Email:     field(record, "email"),
+			City:      field(record, "city"),
+			Frequency: field(record, "frequency"),
+		}
+		if v := strings.TrimSpace(field(record, "digest")); v != "" {
+			if row.Digest, err = strconv.ParseBool(v); err != nil {
+				if err := fn(line, row, fmt.Errorf("digest must be true or false")); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := fn(line, row, nil); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeNDJSON(r io.Reader, fn rowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLine)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var row model.ImportRow
+		err := json.Unmarshal(data, &row)
+		if err != nil {
+			err = fmt.Errorf("invalid JSON")
+		}
+		if err := fn(line, row, err); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read NDJSON at line %d: %w", line+1, err)
+	}
+	return nil
+}
+
+// exportWriter encodes subscriptions one at a time so exports can be streamed.
+type exportWriter interface {
+	Write(sub *model.Subscription) error
+	Flush() error
+}
+
+func newExportWriter(w io.Writer, format string) (exportWriter, error) {
+	switch format {
+	case FormatCSV:
+		cw := &csvExportWriter{w: csv.NewWriter(w)}
+		if err := cw.w.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case FormatNDJSON:
+		return &ndjsonExportWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) Write(sub *model.Subscription) error {
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return c.w.Write([]string{
+		sub.ID,
+		csvCell(sub.Email),
+		csvCell(sub.City),
+		csvCell(sub.Frequency),
+		strconv.FormatBool(sub.Confirmed),
+		strconv.FormatBool(sub.Digest),
+		sub.CreatedAt.UTC().Format(time.RFC3339),
+		optionalTime(sub.ConfirmedAt),
+		optionalTime(sub.PausedUntil),
+	})
+}
+
+// formulaPrefixes are the leading characters that make spreadsheets evaluate a cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// csvCell neutralises a free-text value that a spreadsheet would run as a formula by prefixing it with
+// an apostrophe, which spreadsheets show as text. decodeCSV strips the apostrophe again on import.
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune(formulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// uncsvCell reverses csvCell.
+func uncsvCell(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonExportWriter) Write(sub *model.Subscription) error {
+	return n.enc.Encode(model.NewAdminSubscription(sub))
+}
+
+func (n *ndjsonExportWriter) Flush() error {
+	return nil
+}
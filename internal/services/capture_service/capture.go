@@ -0,0 +1,93 @@
+package capture_service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+)
+
+// CaptureService keeps the emails of dry runs. With DRY_RUN every email is captured; otherwise only those
+// sent with a context marked by client.WithDryRun, such as by an admin request with ?dry_run=true.
+// Everything up to the mail server runs as usual, so a captured email is exactly what would have been sent.
+type CaptureService struct {
+	repo repository.CaptureRepository
+	cfg  *config.Config
+}
+
+func NewCaptureService(repo repository.CaptureRepository, cfg *config.Config) *CaptureService {
+	return &CaptureService{repo: repo, cfg: cfg}
+}
+
+// DryRun reports whether an email sent with ctx is captured instead of sent.
+func (s *CaptureService) DryRun(ctx context.Context) bool {
+	return s.cfg.DryRun || client.IsDryRun(ctx)
+}
+
+// Route wraps the email client that talks to the mail server, so that dry-run emails are captured instead.
+// It goes innermost, below the suppression guard and the delivery log.
+func (s *CaptureService) Route(inner client.Client) client.Client {
+	return &routedClient{inner: inner, captures: s}
+}
+
+type routedClient struct {
+	inner    client.Client
+	captures *CaptureService
+}
+
+func (r *routedClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	if !r.captures.DryRun(ctx) {
+		return r.inner.SendEmail(ctx, to, subject, body, headers...)
+	}
+	return r.captures.capture(ctx, to, subject, body, headers)
+}
+
+func (s *CaptureService) capture(ctx context.Context, to, subject string, body client.Body, headers []client.Header) error {
+	email := &model.CapturedEmail{
+		To:         to,
+		Subject:    subject,
+		HTML:       body.HTML,
+		Text:       body.Text,
+		CapturedAt: time.Now(),
+	}
+	if len(headers) > 0 {
+		email.Headers = make(map[string]string, len(headers))
+		for _, h := range headers {
+			email.Headers[h.Name] = h.Value
+		}
+	}
+	if err := s.repo.Save(ctx, email); err != nil {
+		return fmt.Errorf("failed to capture email: %w", err)
+	}
+
+	logger.Info(ctx, "Email captured instead of sent (dry run)",
+		slog.Int64("capture_id", email.ID),
+		slog.String("to", to),
+		slog.String("subject", subject))
+	return nil
+}
+
+// List returns one page of captured emails, most recent first, and the total number of matches.
+func (s *CaptureService) List(ctx context.Context, filter model.CapturedEmailFilter) ([]*model.CapturedEmail, int, error) {
+	items, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list captured emails: %w", err)
+	}
+	return items, total, nil
+}
+
+// Clear removes every captured email and returns how many there were.
+func (s *CaptureService) Clear(ctx context.Context) (int64, error) {
+	n, err := s.repo.DeleteAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear captured emails: %w", err)
+	}
+	logger.Info(ctx, "Captured emails cleared", slog.Int64("count", n))
+	return n, nil
+}
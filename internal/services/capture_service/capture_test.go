@@ -0,0 +1,92 @@
+package capture_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailClient struct {
+	sent []string
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.sent = append(c.sent, to)
+	return nil
+}
+
+func TestRoute(t *testing.T) {
+	headers := client.UnsubscribeHeaders("http://localhost:8080/api/subscription/unsubscribe/abc")
+
+	tests := []struct {
+		name      string
+		cfgDryRun bool
+		ctxDryRun bool
+		mockSetup func(*repository.MockCaptureRepository)
+		wantErr   bool
+		wantSent  bool
+	}{
+		{
+			name:      "Emails reach the mail server outside a dry run",
+			mockSetup: func(m *repository.MockCaptureRepository) {},
+			wantSent:  true,
+		},
+		{
+			name:      "DRY_RUN captures every email",
+			cfgDryRun: true,
+			mockSetup: func(m *repository.MockCaptureRepository) {
+				m.On("Save", mock.Anything, mock.MatchedBy(func(e *model.CapturedEmail) bool {
+					return e.To == "user@example.com" && e.Subject == "Kyiv forecast" && e.HTML == "<p>Sunny</p>" && e.Text == "Sunny" &&
+						e.Headers["List-Unsubscribe-Post"] == "List-Unsubscribe=One-Click" && !e.CapturedAt.IsZero()
+				})).Return(nil)
+			},
+		},
+		{
+			name:      "A dry run requested for the context is captured",
+			ctxDryRun: true,
+			mockSetup: func(m *repository.MockCaptureRepository) {
+				m.On("Save", mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:      "An email that cannot be captured fails instead of being sent",
+			ctxDryRun: true,
+			mockSetup: func(m *repository.MockCaptureRepository) {
+				m.On("Save", mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockCaptureRepository)
+			tt.mockSetup(repo)
+			svc := NewCaptureService(repo, &config.Config{DryRun: tt.cfgDryRun})
+			server := &fakeEmailClient{}
+
+			ctx := context.Background()
+			if tt.ctxDryRun {
+				ctx = client.WithDryRun(ctx)
+			}
+			err := svc.Route(server).SendEmail(ctx, "user@example.com", "Kyiv forecast", client.Body{HTML: "<p>Sunny</p>", Text: "Sunny"}, headers...)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantSent, len(server.sent) == 1)
+			repo.AssertExpectations(t)
+		})
+	}
+}
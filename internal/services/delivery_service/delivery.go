@@ -0,0 +1,207 @@
+package delivery_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+)
+
+type deliveriesKey struct{}
+
+// DeliveryService keeps the delivery log. The scheduler queues the deliveries an email is about to make
+// and sends it with the returned context; the email client wrapped by Track then records how the send went.
+// The log is best effort: failing to write it is logged and never stops an email.
+//
+// Transient failures are scheduled for a retry with exponential backoff until DELIVERY_MAX_ATTEMPTS
+// attempts have been made. Permanent failures and deliveries out of attempts stay failed; they are
+// the dead-letter queue, from which an admin can replay them.
+type DeliveryService struct {
+	repo repository.DeliveryRepository
+	cfg  *config.Config
+}
+
+func NewDeliveryService(repo repository.DeliveryRepository, cfg *config.Config) *DeliveryService {
+	return &DeliveryService{repo: repo, cfg: cfg}
+}
+
+// Queue records the deliveries as queued and returns a context carrying them, so the outcome of the
+// email sent with it is recorded against them.
+func (s *DeliveryService) Queue(ctx context.Context, deliveries ...*model.Delivery) context.Context {
+	if len(deliveries) == 0 {
+		return ctx
+	}
+	if err := s.repo.Queue(ctx, deliveries, time.Now()); err != nil {
+		logger.Error(ctx, fmt.Errorf("failed to queue deliveries: %w", err),
+			slog.String("email", deliveries[0].Email))
+		return ctx
+	}
+	return s.Resume(ctx, deliveries...)
+}
+
+// Resume returns a context carrying deliveries that are already in the log, such as claimed retries,
+// so the outcome of the email sent with it is recorded against them.
+func (s *DeliveryService) Resume(ctx context.Context, deliveries ...*model.Delivery) context.Context {
+	return context.WithValue(ctx, deliveriesKey{}, deliveries)
+}
+
+// Finish records the outcome of the email sent with a context returned by Queue or Resume: sent when err
+// is nil, or captured in a dry run, suppressed when the address is on the suppression list, skipped when
+// the email is no longer wanted and otherwise
+// retrying or failed, depending on the kind of failure and the attempts left. Contexts without deliveries
+// are ignored.
+func (s *DeliveryService) Finish(ctx context.Context, err error) {
+	deliveries, _ := ctx.Value(deliveriesKey{}).([]*model.Delivery)
+	if len(deliveries) == 0 {
+		return
+	}
+
+	outcome := s.outcome(deliveries, err, time.Now())
+	if outcome.Status == model.DeliverySent && (s.cfg.DryRun || client.IsDryRun(ctx)) {
+		outcome.Status = model.DeliveryCaptured
+	}
+	ids := make([]int64, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	if err := s.repo.Finish(ctx, ids, outcome); err != nil {
+		logger.Error(ctx, fmt.Errorf("failed to record delivery outcome: %w", err),
+			slog.String("status", outcome.Status))
+		return
+	}
+	if outcome.Status == model.DeliveryFailed {
+		logger.Info(ctx, "Delivery moved to the dead-letter queue",
+			slog.String("email", deliveries[0].Email),
+			slog.String("failure", outcome.Failure),
+			slog.String("response", outcome.ProviderResponse))
+	}
+}
+
+func (s *DeliveryService) outcome(deliveries []*model.Delivery, err error, now time.Time) model.DeliveryOutcome {
+	switch {
+	case err == nil:
+		return model.DeliveryOutcome{Status: model.DeliverySent, At: now}
+	case errors.Is(err, client.ErrSuppressed):
+		return model.DeliveryOutcome{Status: model.DeliverySuppressed, ProviderResponse: err.Error(), At: now}
+	case errors.Is(err, client.ErrSkipped):
+		return model.DeliveryOutcome{Status: model.DeliverySkipped, ProviderResponse: err.Error(), At: now}
+	}
+
+	outcome := model.DeliveryOutcome{
+		Status:           model.DeliveryFailed,
+		ProviderResponse: err.Error(),
+		Failure:          classify(err),
+		At:               now,
+	}
+	attempts := 0
+	for _, d := range deliveries {
+		attempts = max(attempts, d.Attempts+1)
+	}
+	if outcome.Failure == model.FailureTransient && attempts < s.cfg.DeliveryMaxAttempts {
+		next := now.Add(s.backoff(attempts))
+		outcome.Status = model.DeliveryRetrying
+		outcome.NextAttemptAt = &next
+	}
+	return outcome
+}
+
+// backoff returns how long to wait after the given number of failed attempts: DELIVERY_RETRY_BACKOFF,
+// doubled with every further attempt and capped at DELIVERY_RETRY_MAX_BACKOFF.
+func (s *DeliveryService) backoff(attempts int) time.Duration {
+	wait := s.cfg.DeliveryRetryBackoff
+	for i := 1; i < attempts && wait < s.cfg.DeliveryRetryMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, s.cfg.DeliveryRetryMaxBackoff)
+}
+
+// classify tells failures worth retrying from those that fail the same way every time: a 5xx SMTP reply
+// rejects the address, and WeatherAPI.com answers 400 or 404 for a city it does not know. Everything
+// else, such as 4xx SMTP replies, timeouts and upstream outages, is transient.
+func classify(err error) string {
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600 {
+		return model.FailurePermanent
+	}
+	var apiErr *client.WeatherAPIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound) {
+		return model.FailurePermanent
+	}
+	return model.FailureTransient
+}
+
+// ClaimRetries claims up to limit deliveries whose retry is due for owner; see repository.DeliveryRepository.
+func (s *DeliveryService) ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error) {
+	deliveries, err := s.repo.ClaimRetries(ctx, owner, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim delivery retries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RenewRetryClaims extends the retry claims held by owner to leaseUntil and returns the ids still held.
+func (s *DeliveryService) RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error) {
+	ids, err := s.repo.RenewRetryClaims(ctx, owner, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew delivery retry claims: %w", err)
+	}
+	return ids, nil
+}
+
+// DeadLetters returns one page of the deliveries that failed for good, most recent first.
+func (s *DeliveryService) DeadLetters(ctx context.Context, limit, offset int) ([]*model.Delivery, int, error) {
+	return s.List(ctx, model.DeliveryFilter{Status: model.DeliveryFailed, Limit: limit, Offset: offset})
+}
+
+// Replay moves a dead letter, with the rest of its digest, back to the retry queue. The next dispatcher
+// poll sends it with one more attempt.
+func (s *DeliveryService) Replay(ctx context.Context, id int64) error {
+	if err := s.repo.Requeue(ctx, id, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDeadLetterNotFound
+		}
+		return fmt.Errorf("failed to replay delivery: %w", err)
+	}
+	logger.Info(ctx, "Dead letter queued for replay", slog.Int64("delivery_id", id))
+	return nil
+}
+
+// Track wraps an email client so that every email sent with a context returned by Queue updates the log.
+func (s *DeliveryService) Track(inner client.Client) client.Client {
+	return &trackedClient{inner: inner, deliveries: s}
+}
+
+type trackedClient struct {
+	inner      client.Client
+	deliveries *DeliveryService
+}
+
+func (t *trackedClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	err := t.inner.SendEmail(ctx, to, subject, body, headers...)
+	t.deliveries.Finish(ctx, err)
+	return err
+}
+
+// List returns one page of the delivery log, most recent first, and the total number of matches.
+func (s *DeliveryService) List(ctx context.Context, filter model.DeliveryFilter) ([]*model.Delivery, int, error) {
+	items, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return items, total, nil
+}
+
+// Recent returns the latest deliveries made to a subscriber across all of their subscriptions.
+func (s *DeliveryService) Recent(ctx context.Context, email string, limit int) ([]*model.Delivery, error) {
+	items, _, err := s.List(ctx, model.DeliveryFilter{Email: email, Limit: limit})
+	return items, err
+}
@@ -0,0 +1,224 @@
+package delivery_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailClient struct {
+	err error
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	return c.err
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		DeliveryMaxAttempts:     3,
+		DeliveryRetryBackoff:    time.Minute,
+		DeliveryRetryMaxBackoff: 3 * time.Minute,
+	}
+}
+
+// outcome matches a recorded outcome by status and failure class.
+func outcome(status, failure string) any {
+	return mock.MatchedBy(func(o model.DeliveryOutcome) bool {
+		return o.Status == status && o.Failure == failure &&
+			(status == model.DeliveryRetrying) == (o.NextAttemptAt != nil)
+	})
+}
+
+func TestTrack(t *testing.T) {
+	slot := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	newDeliveries := func() []*model.Delivery {
+		return []*model.Delivery{
+			{SubscriptionID: "1", Email: "user@example.com", City: "Kyiv", Kind: model.DeliveryKindDigest, ScheduledFor: slot},
+			{SubscriptionID: "2", Email: "user@example.com", City: "Lviv", Kind: model.DeliveryKindDigest, ScheduledFor: slot},
+		}
+	}
+	// queued assigns IDs the way the database does
+	queued := func(args mock.Arguments) {
+		for i, d := range args.Get(1).([]*model.Delivery) {
+			d.ID = int64(10 + i)
+		}
+	}
+	suppressed := fmt.Errorf("%w: user@example.com", client.ErrSuppressed)
+
+	tests := []struct {
+		name      string
+		sendErr   error
+		queue     bool
+		dryRun    bool
+		mockSetup func(*repository.MockDeliveryRepository)
+	}{
+		{
+			name:  "Sent email marks every queued delivery sent",
+			queue: true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliverySent, "")).Return(nil)
+			},
+		},
+		{
+			name:   "Email captured by a dry run marks every queued delivery captured",
+			queue:  true,
+			dryRun: true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliveryCaptured, "")).Return(nil)
+			},
+		},
+		{
+			name:    "Temporary SMTP rejection is retried",
+			sendErr: &textproto.Error{Code: 451, Msg: "try again later"},
+			queue:   true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliveryRetrying, model.FailureTransient)).Return(nil)
+			},
+		},
+		{
+			name:    "Rejected address goes straight to the dead-letter queue",
+			sendErr: &textproto.Error{Code: 550, Msg: "user unknown"},
+			queue:   true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliveryFailed, model.FailurePermanent)).Return(nil)
+			},
+		},
+		{
+			name:    "Refused email is recorded as suppressed",
+			sendErr: suppressed,
+			queue:   true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliverySuppressed, "")).Return(nil)
+			},
+		},
+		{
+			name:    "Email no longer wanted is recorded as skipped",
+			sendErr: fmt.Errorf("%w: subscription paused", client.ErrSkipped),
+			queue:   true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Run(queued).Return(nil)
+				m.On("Finish", mock.Anything, []int64{10, 11}, outcome(model.DeliverySkipped, "")).Return(nil)
+			},
+		},
+		{
+			name:      "Emails without queued deliveries are not logged",
+			mockSetup: func(m *repository.MockDeliveryRepository) {},
+		},
+		{
+			name:  "Email is still sent when the log cannot be written",
+			queue: true,
+			mockSetup: func(m *repository.MockDeliveryRepository) {
+				m.On("Queue", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockDeliveryRepository)
+			tt.mockSetup(repo)
+			svc := NewDeliveryService(repo, newTestConfig())
+
+			ctx := context.Background()
+			if tt.dryRun {
+				ctx = client.WithDryRun(ctx)
+			}
+			if tt.queue {
+				ctx = svc.Queue(ctx, newDeliveries()...)
+			}
+			err := svc.Track(&fakeEmailClient{err: tt.sendErr}).SendEmail(ctx, "user@example.com", "subject", client.Body{Text: "body"})
+			require.Equal(t, tt.sendErr, err, "Tracking must not change the outcome of a send")
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewDeliveryService(nil, newTestConfig())
+	timeout := fmt.Errorf("failed to fetch weather data: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name        string
+		attempts    int
+		err         error
+		wantStatus  string
+		wantFailure string
+		wantRetryIn time.Duration
+	}{
+		{
+			name:        "First transient failure waits the base backoff",
+			err:         timeout,
+			wantStatus:  model.DeliveryRetrying,
+			wantFailure: model.FailureTransient,
+			wantRetryIn: time.Minute,
+		},
+		{
+			name:        "Backoff doubles with every attempt",
+			attempts:    1,
+			err:         timeout,
+			wantStatus:  model.DeliveryRetrying,
+			wantFailure: model.FailureTransient,
+			wantRetryIn: 2 * time.Minute,
+		},
+		{
+			name:        "Exhausted deliveries are dead-lettered",
+			attempts:    2,
+			err:         timeout,
+			wantStatus:  model.DeliveryFailed,
+			wantFailure: model.FailureTransient,
+		},
+		{
+			name:        "Unknown city is permanent",
+			err:         fmt.Errorf("weather for Atlantis is unavailable: %w", &client.WeatherAPIError{StatusCode: 400, Status: "400 Bad Request"}),
+			wantStatus:  model.DeliveryFailed,
+			wantFailure: model.FailurePermanent,
+		},
+		{
+			name:        "Upstream outage is transient",
+			err:         &client.WeatherAPIError{StatusCode: 503, Status: "503 Service Unavailable"},
+			wantStatus:  model.DeliveryRetrying,
+			wantFailure: model.FailureTransient,
+			wantRetryIn: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := svc.outcome([]*model.Delivery{{Attempts: tt.attempts}}, tt.err, now)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantFailure, got.Failure)
+			assert.Equal(t, tt.err.Error(), got.ProviderResponse)
+			if tt.wantRetryIn == 0 {
+				assert.Nil(t, got.NextAttemptAt)
+			} else {
+				require.NotNil(t, got.NextAttemptAt)
+				assert.Equal(t, now.Add(tt.wantRetryIn), *got.NextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	svc := NewDeliveryService(nil, newTestConfig())
+	assert.Equal(t, 3*time.Minute, svc.backoff(3))
+	assert.Equal(t, 3*time.Minute, svc.backoff(50))
+}
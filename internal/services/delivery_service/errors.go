@@ -0,0 +1,5 @@
+package delivery_service
+
+import "errors"
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
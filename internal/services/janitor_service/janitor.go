@@ -0,0 +1,74 @@
+package janitor_service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/clock"
+)
+
+// JanitorService periodically purges pending subscriptions that were never confirmed.
+type JanitorService struct {
+	repo  repository.SubscriptionRepository
+	cfg   *config.Config
+	clock clock.Clock
+}
+
+func NewJanitorService(repo repository.SubscriptionRepository, cfg *config.Config) *JanitorService {
+	return &JanitorService{
+		repo:  repo,
+		cfg:   cfg,
+		clock: clock.Real(),
+	}
+}
+
+// WithClock replaces the clock that sets the retention cutoff and paces the cleanup loop.
+func (j *JanitorService) WithClock(c clock.Clock) *JanitorService {
+	j.clock = c
+	return j
+}
+
+// Start runs the cleanup loop in the background until the context is cancelled.
+func (j *JanitorService) Start(ctx context.Context) {
+	go j.run(ctx)
+	logger.Info(ctx, "Pending subscription janitor started",
+		slog.Duration("interval", j.cfg.PendingCleanupInterval),
+		slog.Duration("retention", j.cfg.PendingRetention))
+}
+
+func (j *JanitorService) run(ctx context.Context) {
+	ticker := j.clock.NewTicker(j.cfg.PendingCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.PurgeStalePending(ctx); err != nil {
+			logger.Error(ctx, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "Stopping pending subscription janitor")
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// PurgeStalePending deletes pending subscriptions older than the retention window and returns how many were removed.
+func (j *JanitorService) PurgeStalePending(ctx context.Context) (int64, error) {
+	cutoff := j.clock.Now().Add(-j.cfg.PendingRetention)
+
+	removed, err := j.repo.DeletePendingCreatedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge pending subscriptions: %w", err)
+	}
+
+	logger.Info(ctx, "Purged stale pending subscriptions",
+		slog.Int64("removed", removed),
+		slog.Time("cutoff", cutoff))
+	return removed, nil
+}
@@ -0,0 +1,84 @@
+package janitor_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/clock"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeStalePending(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{PendingRetention: 48 * time.Hour, PendingCleanupInterval: time.Hour}
+	errDB := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		removed     int64
+		repoErr     error
+		expectedErr error
+	}{
+		{
+			name:    "Pending subscriptions older than the retention window are removed",
+			removed: 3,
+		},
+		{
+			name:        "Repository failure is reported and nothing counts as removed",
+			removed:     5,
+			repoErr:     errDB,
+			expectedErr: errDB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			// The cutoff is exactly the retention window before now
+			repo.On("DeletePendingCreatedBefore", mock.Anything, now.Add(-48*time.Hour)).Return(tt.removed, tt.repoErr).Once()
+
+			j := NewJanitorService(repo, cfg).WithClock(clock.NewFake(now))
+			removed, err := j.PurgeStalePending(context.Background())
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.Zero(t, removed)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.removed, removed)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJanitorRunsEveryInterval(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{PendingRetention: 48 * time.Hour, PendingCleanupInterval: time.Hour}
+	c := clock.NewFake(start)
+
+	purged := make(chan time.Time, 3)
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("DeletePendingCreatedBefore", mock.Anything, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { purged <- args.Get(1).(time.Time) }).
+		Return(int64(0), errors.New("connection refused")).Once()
+	repo.On("DeletePendingCreatedBefore", mock.Anything, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { purged <- args.Get(1).(time.Time) }).
+		Return(int64(1), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewJanitorService(repo, cfg).WithClock(c).Start(ctx)
+
+	require.Equal(t, start.Add(-48*time.Hour), <-purged, "The first purge runs right away")
+	require.Eventually(t, func() bool { return c.Tickers() == 1 }, time.Second, time.Millisecond)
+
+	c.Advance(time.Hour)
+	require.Equal(t, start.Add(-47*time.Hour), <-purged, "A failed purge does not stop the loop")
+}
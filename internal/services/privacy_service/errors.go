@@ -0,0 +1,26 @@
+package privacy_service
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidToken    = errors.New("invalid privacy token")
+	ErrTokenExpired    = errors.New("privacy token expired")
+	ErrRequestCooldown = errors.New("privacy link was requested recently")
+)
+
+// RetryAfterError wraps a refusal that may succeed after RetryAfter.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,206 @@
+package privacy_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/ratelimit"
+	"Weather-API-Application/internal/utils/token"
+)
+
+// Actions a data subject can request.
+const (
+	ActionExport = "export"
+	ActionErase  = "erase"
+)
+
+// PrivacyService answers data-subject requests: exporting and erasing everything held about an email.
+// Both are authorised by an expiring link emailed to that address.
+type PrivacyService struct {
+	repo        repository.SubscriptionRepository
+	deliveries  repository.DeliveryRepository
+	emailClient client.Client
+	cfg         *config.Config
+	tokens      *token.Hasher
+	cooldown    *ratelimit.Limiter
+}
+
+// exportPageSize is how many deliveries an export reads at a time.
+const exportPageSize = 200
+
+func NewPrivacyService(repo repository.SubscriptionRepository, deliveries repository.DeliveryRepository, emailClient client.Client, cfg *config.Config) *PrivacyService {
+	return &PrivacyService{
+		repo:        repo,
+		deliveries:  deliveries,
+		emailClient: emailClient,
+		cfg:         cfg,
+		tokens:      token.NewHasher(cfg.TokenSecret),
+		cooldown:    newCooldown(cfg.PrivacyRequestCooldown),
+	}
+}
+
+// newCooldown allows one request per email within the cooldown; a zero cooldown disables it.
+func newCooldown(cooldown time.Duration) *ratelimit.Limiter {
+	if cooldown <= 0 {
+		return ratelimit.New(0, 0)
+	}
+	return ratelimit.New(1, cooldown)
+}
+
+// RequestLink emails a verification link for the action. Nothing is sent when no data is held,
+// but the caller cannot tell the difference, so the endpoint does not reveal who is subscribed.
+// An email gets one request per PRIVACY_REQUEST_COOLDOWN, held or not, and later ones fail with ErrRequestCooldown.
+func (s *PrivacyService) RequestLink(ctx context.Context, email, action string) error {
+	if ok, wait := s.cooldown.Allow(strings.ToLower(strings.TrimSpace(email))); !ok {
+		logger.Info(ctx, "Privacy request refused during cooldown", slog.String("action", action))
+		return &RetryAfterError{Err: ErrRequestCooldown, RetryAfter: wait}
+	}
+
+	subs, err := s.repo.ListByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to look up subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		logger.Info(ctx, "Privacy request for unknown email ignored", slog.String("action", action))
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.cfg.PrivacyLinkTTL)
+	switch action {
+	case ActionExport:
+		exportURL := config.BuildPrivacyExportURL(s.cfg.BaseURL, s.tokens.SignExpiring(token.PurposePrivacyExport, email, expiresAt))
+		err = client.SendPrivacyExport(ctx, email, exportURL, s.emailClient)
+	case ActionErase:
+		eraseURL := config.BuildPrivacyEraseURL(s.cfg.BaseURL, s.tokens.SignExpiring(token.PurposePrivacyErase, email, expiresAt))
+		err = client.SendPrivacyErase(ctx, email, eraseURL, s.emailClient)
+	default:
+		return fmt.Errorf("unknown privacy action %q", action)
+	}
+
+	if err != nil {
+		// Answer as if the link was sent so the response does not reveal that the address is suppressed
+		if errors.Is(err, client.ErrSuppressed) {
+			logger.Info(ctx, "Privacy link not sent, address suppressed",
+				slog.String("email", email),
+				slog.String("action", action))
+			return nil
+		}
+		return fmt.Errorf("failed to send privacy email: %w", err)
+	}
+	logger.Info(ctx, "Privacy link sent",
+		slog.String("email", email),
+		slog.String("action", action))
+	return nil
+}
+
+// Export returns all data held about the email the token was issued for: its subscriptions and the
+// delivery log of the emails sent to it.
+func (s *PrivacyService) Export(ctx context.Context, exportToken string) (*model.DataExport, error) {
+	email, err := s.verify(token.PurposePrivacyExport, exportToken)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.repo.ListByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	export := &model.DataExport{
+		Email:         email,
+		GeneratedAt:   time.Now().UTC(),
+		Subscriptions: make([]model.SubscriptionExportRecord, 0, len(subs)),
+	}
+	for _, sub := range subs {
+		export.Subscriptions = append(export.Subscriptions, model.SubscriptionExportRecord{
+			City:        sub.City,
+			Frequency:   sub.Frequency,
+			Confirmed:   sub.Confirmed,
+			Digest:      sub.Digest,
+			QuietHours:  sub.QuietHours,
+			CreatedAt:   sub.CreatedAt,
+			ConfirmedAt: sub.ConfirmedAt,
+			PausedUntil: sub.PausedUntil,
+		})
+	}
+
+	if export.Deliveries, err = s.exportDeliveries(ctx, email); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "Personal data exported",
+		slog.String("email", email),
+		slog.Int("subscriptions", len(subs)),
+		slog.Int("deliveries", len(export.Deliveries)))
+	return export, nil
+}
+
+// exportDeliveries reads the whole delivery log of the email, most recent first.
+func (s *PrivacyService) exportDeliveries(ctx context.Context, email string) ([]model.DeliveryExportRecord, error) {
+	records := []model.DeliveryExportRecord{}
+	for offset := 0; ; offset += exportPageSize {
+		page, total, err := s.deliveries.List(ctx, model.DeliveryFilter{Email: email, Limit: exportPageSize, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deliveries: %w", err)
+		}
+		for _, d := range page {
+			records = append(records, model.DeliveryExportRecord{
+				City:             d.City,
+				Kind:             d.Kind,
+				ScheduledFor:     d.ScheduledFor,
+				Status:           d.Status,
+				Attempts:         d.Attempts,
+				ProviderResponse: d.ProviderResponse,
+				CreatedAt:        d.CreatedAt,
+				SentAt:           d.SentAt,
+			})
+		}
+		if len(page) == 0 || offset+len(page) >= total {
+			return records, nil
+		}
+	}
+}
+
+// Erase permanently deletes everything tied to the email the token was issued for, which ends its
+// deliveries, and records an audit entry that keeps only a keyed hash of the email.
+// It returns the number of subscriptions removed; a repeated request removes nothing but is still audited.
+func (s *PrivacyService) Erase(ctx context.Context, eraseToken string) (int64, error) {
+	email, err := s.verify(token.PurposePrivacyErase, eraseToken)
+	if err != nil {
+		return 0, err
+	}
+
+	audit := &model.ErasureAudit{
+		EmailHash: s.tokens.Hash(email),
+		ErasedAt:  time.Now(),
+	}
+	if err := s.repo.EraseByEmail(ctx, email, audit); err != nil {
+		return 0, fmt.Errorf("failed to erase personal data: %w", err)
+	}
+
+	// The email itself is not logged; the hash ties the log line to the audit entry
+	logger.Info(ctx, "Personal data erased",
+		slog.String("email_hash", audit.EmailHash),
+		slog.Int64("subscriptions", audit.SubscriptionsRemoved))
+	return audit.SubscriptionsRemoved, nil
+}
+
+func (s *PrivacyService) verify(purpose, signed string) (string, error) {
+	email, err := s.tokens.VerifyExpiring(purpose, signed, time.Now())
+	switch {
+	case errors.Is(err, token.ErrExpired):
+		return "", ErrTokenExpired
+	case err != nil:
+		return "", ErrInvalidToken
+	}
+	return email, nil
+}
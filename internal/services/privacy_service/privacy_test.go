@@ -0,0 +1,185 @@
+package privacy_service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sentEmail struct {
+	to, subject string
+	body        client.Body
+}
+
+type fakeEmailClient struct {
+	sent []sentEmail
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.sent = append(c.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{TokenSecret: "secret", BaseURL: "http://localhost", PrivacyLinkTTL: time.Hour}
+}
+
+func TestRequestLink(t *testing.T) {
+	cfg := newTestConfig()
+
+	tests := []struct {
+		name        string
+		existing    []*model.Subscription
+		action      string
+		wantSubject string
+		wantLink    string
+	}{
+		{
+			name:     "Unknown email gets no email",
+			existing: nil,
+			action:   ActionExport,
+		},
+		{
+			name:        "Export link is sent to a subscriber",
+			existing:    []*model.Subscription{{Email: "user@example.com", City: "Kyiv"}},
+			action:      ActionExport,
+			wantSubject: config.PrivacyExportSubject,
+			wantLink:    "http://localhost/api/privacy/export/",
+		},
+		{
+			name:        "Erasure link points at the confirmation page",
+			existing:    []*model.Subscription{{Email: "user@example.com", City: "Kyiv"}},
+			action:      ActionErase,
+			wantSubject: config.PrivacyEraseSubject,
+			wantLink:    "http://localhost/static/erase.html?token=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			repo.On("ListByEmail", mock.Anything, "user@example.com").Return(tt.existing, nil)
+			emails := &fakeEmailClient{}
+
+			svc := NewPrivacyService(repo, nil, emails, cfg)
+			require.NoError(t, svc.RequestLink(context.Background(), "user@example.com", tt.action))
+
+			if tt.wantSubject == "" {
+				require.Empty(t, emails.sent)
+				return
+			}
+			require.Len(t, emails.sent, 1)
+			require.Equal(t, "user@example.com", emails.sent[0].to)
+			require.Equal(t, tt.wantSubject, emails.sent[0].subject)
+			require.Contains(t, emails.sent[0].body.HTML, tt.wantLink)
+			require.Contains(t, emails.sent[0].body.Text, tt.wantLink)
+		})
+	}
+}
+
+func TestRequestLinkCooldown(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PrivacyRequestCooldown = time.Minute
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ListByEmail", mock.Anything, "user@example.com").Return([]*model.Subscription{{Email: "user@example.com", City: "Kyiv"}}, nil).Once()
+	repo.On("ListByEmail", mock.Anything, "other@example.com").Return(nil, nil).Once()
+	emails := &fakeEmailClient{}
+	svc := NewPrivacyService(repo, nil, emails, cfg)
+
+	require.NoError(t, svc.RequestLink(context.Background(), "user@example.com", ActionExport))
+
+	err := svc.RequestLink(context.Background(), " User@Example.com", ActionErase)
+	require.ErrorIs(t, err, ErrRequestCooldown, "Addresses differing in case or spacing share the cooldown")
+	var retryErr *RetryAfterError
+	require.ErrorAs(t, err, &retryErr)
+	require.Positive(t, retryErr.RetryAfter)
+
+	require.NoError(t, svc.RequestLink(context.Background(), "other@example.com", ActionExport))
+	require.ErrorIs(t, svc.RequestLink(context.Background(), "other@example.com", ActionExport), ErrRequestCooldown,
+		"Emails without data are held to the cooldown too, so it does not reveal who is subscribed")
+
+	require.Len(t, emails.sent, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestExport(t *testing.T) {
+	cfg := newTestConfig()
+	hasher := token.NewHasher(cfg.TokenSecret)
+	confirmedAt := time.Now().Add(-time.Hour)
+	subs := []*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "daily", Confirmed: true, ConfirmedAt: &confirmedAt},
+		{ID: "2", Email: "user@example.com", City: "Lviv", Frequency: "hourly"},
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ListByEmail", mock.Anything, "user@example.com").Return(subs, nil)
+	sentAt := time.Now().Add(-30 * time.Minute)
+	deliveries := new(repository.MockDeliveryRepository)
+	firstPage := make([]*model.Delivery, exportPageSize)
+	for i := range firstPage {
+		firstPage[i] = &model.Delivery{ID: int64(i + 2), Email: "user@example.com", City: "Kyiv", Kind: model.DeliveryKindUpdate, Status: model.DeliverySent, Attempts: 1, SentAt: &sentAt}
+	}
+	deliveries.On("List", mock.Anything, model.DeliveryFilter{Email: "user@example.com", Limit: exportPageSize}).
+		Return(firstPage, exportPageSize+1, nil).Once()
+	deliveries.On("List", mock.Anything, model.DeliveryFilter{Email: "user@example.com", Limit: exportPageSize, Offset: exportPageSize}).
+		Return([]*model.Delivery{{ID: 1, Email: "user@example.com", City: "Lviv", Kind: model.DeliveryKindDigest, Status: model.DeliveryFailed, Attempts: 3, ProviderResponse: "550 user unknown"}}, exportPageSize+1, nil).Once()
+	svc := NewPrivacyService(repo, deliveries, nil, cfg)
+
+	export, err := svc.Export(context.Background(),
+		hasher.SignExpiring(token.PurposePrivacyExport, "user@example.com", time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	require.Equal(t, "user@example.com", export.Email)
+	require.Len(t, export.Subscriptions, 2)
+	require.Equal(t, "Kyiv", export.Subscriptions[0].City)
+	require.Equal(t, &confirmedAt, export.Subscriptions[0].ConfirmedAt)
+	require.False(t, export.Subscriptions[1].Confirmed)
+	require.Len(t, export.Deliveries, exportPageSize+1, "The whole delivery history is exported, however many pages it takes")
+	require.Equal(t, &sentAt, export.Deliveries[0].SentAt)
+	last := export.Deliveries[exportPageSize]
+	require.Equal(t, "Lviv", last.City)
+	require.Equal(t, model.DeliveryFailed, last.Status)
+	require.Equal(t, "550 user unknown", last.ProviderResponse)
+	deliveries.AssertExpectations(t)
+
+	_, err = svc.Export(context.Background(),
+		hasher.SignExpiring(token.PurposePrivacyExport, "user@example.com", time.Now().Add(-time.Minute)))
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = svc.Export(context.Background(),
+		hasher.SignExpiring(token.PurposePrivacyErase, "user@example.com", time.Now().Add(time.Hour)))
+	require.ErrorIs(t, err, ErrInvalidToken, "An erasure link must not export data")
+}
+
+func TestErase(t *testing.T) {
+	cfg := newTestConfig()
+	hasher := token.NewHasher(cfg.TokenSecret)
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("EraseByEmail", mock.Anything, "user@example.com", mock.MatchedBy(func(a *model.ErasureAudit) bool {
+		return a.EmailHash == hasher.Hash("user@example.com") && !strings.Contains(a.EmailHash, "user@example.com")
+	})).Run(func(args mock.Arguments) {
+		args.Get(2).(*model.ErasureAudit).SubscriptionsRemoved = 3
+	}).Return(nil)
+	svc := NewPrivacyService(repo, nil, nil, cfg)
+
+	removed, err := svc.Erase(context.Background(),
+		hasher.SignExpiring(token.PurposePrivacyErase, "user@example.com", time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), removed)
+	repo.AssertExpectations(t)
+
+	_, err = svc.Erase(context.Background(),
+		hasher.SignExpiring(token.PurposePrivacyExport, "user@example.com", time.Now().Add(time.Hour)))
+	require.ErrorIs(t, err, ErrInvalidToken, "An export link must not erase data")
+}
@@ -0,0 +1,73 @@
+package scheduler_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/utils/metrics"
+)
+
+// ScheduleChangesChannel is the Postgres channel on which the database announces changes to subscriptions
+// and to the scheduler state.
+const ScheduleChangesChannel = "schedule_changes"
+
+var metricWakeups = metrics.NewCounter("scheduler_wakeups")
+
+// scheduleChange is the payload of a notification on ScheduleChangesChannel. NextRunAt is a UTC timestamp
+// without a zone, as stored.
+type scheduleChange struct {
+	Source    string  `json:"source"`
+	Op        string  `json:"op"`
+	Confirmed bool    `json:"confirmed"`
+	NextRunAt *string `json:"next_run_at"`
+}
+
+// HandleChange polls right away, rather than on the next tick, when a notification announces something
+// the dispatcher acts on: a confirmed subscription that is due or not scheduled yet, such as one fixed by
+// hand or imported, or a pause or resume. An empty payload, sent when notifications may have been missed,
+// always triggers a poll. Other changes are picked up by the regular polls.
+func (s *SchedulerService) HandleChange(ctx context.Context, payload string) {
+	if payload == "" {
+		s.wakeUp()
+		return
+	}
+
+	var change scheduleChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		logger.Error(ctx, fmt.Errorf("invalid schedule change notification: %w", err))
+		return
+	}
+	if s.actsOn(change) {
+		s.wakeUp()
+	}
+}
+
+func (s *SchedulerService) actsOn(change scheduleChange) bool {
+	switch change.Source {
+	case "scheduler":
+		return true
+	case "subscription":
+		if change.Op == "delete" || !change.Confirmed {
+			return false
+		}
+		if change.NextRunAt == nil {
+			return true
+		}
+		next, err := time.ParseInLocation("2006-01-02T15:04:05.999999", *change.NextRunAt, time.UTC)
+		return err == nil && !next.After(s.clock.Now())
+	}
+	return false
+}
+
+// wakeUp makes the dispatcher poll as soon as it is idle. Wake-ups arriving during a poll are merged
+// into a single one after it.
+func (s *SchedulerService) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+		metricWakeups.Add(1)
+	default:
+	}
+}
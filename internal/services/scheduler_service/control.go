@@ -0,0 +1,72 @@
+package scheduler_service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+)
+
+// WithControl lets the scheduler report on schedules and be paused and resumed at runtime. The pause is
+// kept in the database, so it applies to every instance; without a control repository it never pauses.
+func (s *SchedulerService) WithControl(control repository.SchedulerRepository) *SchedulerService {
+	s.control = control
+	return s
+}
+
+// Schedules returns one page of the schedules of confirmed subscriptions, in the order they fall due.
+func (s *SchedulerService) Schedules(ctx context.Context, filter model.ScheduleFilter) ([]*model.Schedule, int, error) {
+	items, total, err := s.control.ListSchedules(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return items, total, nil
+}
+
+// Status returns whether the scheduler is paused, as seen by this instance.
+func (s *SchedulerService) Status(ctx context.Context) (*model.SchedulerStatus, error) {
+	state, err := s.control.GetState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduler state: %w", err)
+	}
+	return &model.SchedulerStatus{SchedulerState: *state, Instance: s.instanceID}, nil
+}
+
+// Pause stops every instance from sending scheduled updates and retries from its next poll on. A batch
+// already being delivered is finished. On-demand sends are not affected.
+func (s *SchedulerService) Pause(ctx context.Context, reason string) (*model.SchedulerStatus, error) {
+	state, err := s.control.SetPaused(ctx, true, reason, s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to pause scheduler: %w", err)
+	}
+	logger.Info(ctx, "Scheduler paused", slog.String("reason", reason))
+	return &model.SchedulerStatus{SchedulerState: *state, Instance: s.instanceID}, nil
+}
+
+// Resume lets the scheduler send again. Slots that fell due while it was paused are handled as missed
+// slots, according to SCHEDULER_MISSED_SLOTS.
+func (s *SchedulerService) Resume(ctx context.Context) (*model.SchedulerStatus, error) {
+	state, err := s.control.SetPaused(ctx, false, "", s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume scheduler: %w", err)
+	}
+	logger.Info(ctx, "Scheduler resumed")
+	return &model.SchedulerStatus{SchedulerState: *state, Instance: s.instanceID}, nil
+}
+
+// paused reports whether polls should send nothing. A state that cannot be read is logged and does not
+// pause the scheduler, as polling would fail against an unreachable database anyway.
+func (s *SchedulerService) paused(ctx context.Context) bool {
+	if s.control == nil {
+		return false
+	}
+	state, err := s.control.GetState(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Errorf("failed to read scheduler state: %w", err))
+		return false
+	}
+	return state.Paused
+}
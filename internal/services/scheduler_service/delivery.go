@@ -0,0 +1,513 @@
+package scheduler_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/metrics"
+	"Weather-API-Application/internal/utils/token"
+)
+
+// Dispatcher metrics, served on /debug/vars. Latencies are in milliseconds.
+var (
+	metricBatchSize      = metrics.NewSummary("scheduler_batch_size")
+	metricBatchLocations = metrics.NewSummary("scheduler_batch_locations")
+	metricFetchLatency   = metrics.NewSummary("scheduler_fetch_latency_ms")
+	metricFanOutLatency  = metrics.NewSummary("scheduler_fanout_latency_ms")
+	metricFetchErrors    = metrics.NewCounter("scheduler_fetch_errors")
+	metricEmailsSent     = metrics.NewCounter("scheduler_emails_sent")
+	metricEmailsFailed   = metrics.NewCounter("scheduler_emails_failed")
+	metricSendDelay      = metrics.NewSummary("scheduler_send_delay_ms")
+)
+
+type jobKind int
+
+const (
+	jobUpdate jobKind = iota
+	jobCatchUp
+	jobDigest
+	jobQuietSlot
+)
+
+// job is the part of a claimed batch that needs weather: an email to send or a quiet slot to record.
+// Its subscriptions are moved to their next slot once it has run.
+type job struct {
+	kind     jobKind
+	subs     []*model.Subscription
+	sections []*model.Subscription       // digests: the subscriptions included in the email
+	observed []*model.WeatherAPIResponse // catch-up summaries: weather observed in quiet hours
+}
+
+// locationKey identifies a location regardless of how subscribers spelled its case.
+func locationKey(city string) string {
+	return strings.ToLower(strings.TrimSpace(city))
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// deliver sends one claimed batch. Slots that need no weather are completed right away; the weather of
+// every location the rest needs is fetched once, then the emails are sent by a pool of SCHEDULER_WORKERS.
+// A batch is delivered in full even if the scheduler is stopped meanwhile, so no send is cut off halfway
+// and every slot that was sent is also recorded.
+func (s *SchedulerService) deliver(ctx context.Context, subs []*model.Subscription, now, leaseUntil time.Time) {
+	ctx = context.WithoutCancel(ctx)
+	leases := s.keepLeases(ctx, subs, leaseUntil)
+	defer leases.close()
+
+	metricBatchSize.Observe(float64(len(subs)))
+	jobs := s.plan(ctx, leases, subs, now)
+	if len(jobs) == 0 {
+		return
+	}
+
+	weather := s.fetchWeather(ctx, jobCities(jobs))
+
+	start := time.Now()
+	s.fanOut(len(jobs), func(i int) {
+		s.runJob(ctx, leases, jobs[i], weather, now)
+	})
+	metricFanOutLatency.Observe(millis(time.Since(start)))
+}
+
+// plan turns a claimed batch into jobs, completing the subscriptions whose slot sends nothing.
+func (s *SchedulerService) plan(ctx context.Context, leases *leaseKeeper, subs []*model.Subscription, now time.Time) []*job {
+	var (
+		jobs                     []*job
+		scheduled, skipped, lost int
+		digestKeys               []string
+		digests                  = make(map[string][]*model.Subscription)
+	)
+	for _, sub := range subs {
+		switch {
+		case !leases.holds(sub.ID):
+			lost++
+			continue
+		case sub.NextRunAt == nil:
+			// Never scheduled before, e.g. confirmed while no instance was running: only its first slot is set
+			scheduled++
+		case s.missed(*sub.NextRunAt, now):
+			skipped++
+		case sub.Digest:
+			key := makeDigestKey(sub.Email, sub.Frequency)
+			if _, ok := digests[key]; !ok {
+				digestKeys = append(digestKeys, key)
+			}
+			digests[key] = append(digests[key], sub)
+			continue
+		default:
+			if j := s.planUpdate(ctx, sub, now); j != nil {
+				jobs = append(jobs, j)
+				continue
+			}
+		}
+		s.complete(ctx, leases, sub, now)
+	}
+
+	for _, key := range digestKeys {
+		group := digests[key]
+		if j := s.planDigest(ctx, group, now); j != nil {
+			jobs = append(jobs, j)
+			continue
+		}
+		for _, sub := range group {
+			s.complete(ctx, leases, sub, now)
+		}
+	}
+
+	if scheduled > 0 || skipped > 0 || lost > 0 {
+		logger.Info(ctx, "Claimed subscriptions not delivered",
+			slog.Int("unscheduled", scheduled),
+			slog.Int("missed_slots_skipped", skipped),
+			slog.Int("leases_lost", lost))
+	}
+	return jobs
+}
+
+// planUpdate decides what the slot of a single subscription sends, honouring pauses and quiet hours.
+// It returns nil when nothing needs weather.
+func (s *SchedulerService) planUpdate(ctx context.Context, sub *model.Subscription, now time.Time) *job {
+	if s.isSuppressed(ctx, sub.Email) {
+		logger.Info(ctx, "Address suppressed, skipping update",
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+		s.finishDeliveries(s.queueDeliveries(ctx, model.DeliveryKindUpdate, sub), client.ErrSuppressed)
+		return nil
+	}
+	if sub.IsPaused(now) {
+		logger.Info(ctx, "Subscription paused, skipping update",
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City),
+			slog.Time("paused_until", *sub.PausedUntil))
+		return nil
+	}
+
+	wantsCatchUp := sub.QuietHours != nil && sub.QuietHours.CatchUpSummary
+	if sub.IsQuiet(now) {
+		logger.Info(ctx, "Quiet hours, skipping update",
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+		if wantsCatchUp {
+			return &job{kind: jobQuietSlot, subs: []*model.Subscription{sub}}
+		}
+		return nil
+	}
+
+	if backlog := sub.QuietBacklog; backlog != nil {
+		// Cleared before sending, so a failed summary is not resent with every later slot
+		if err := s.repo.SetQuietBacklog(ctx, sub.ID, nil); err != nil {
+			logger.Error(ctx, fmt.Errorf("failed to clear quiet hours backlog: %w", err),
+				slog.String("email", sub.Email),
+				slog.String("city", sub.City))
+		}
+		if wantsCatchUp && backlog.Skipped > 0 {
+			return &job{kind: jobCatchUp, subs: []*model.Subscription{sub}, observed: backlog.Observed}
+		}
+	}
+	return &job{kind: jobUpdate, subs: []*model.Subscription{sub}}
+}
+
+// planDigest decides what the slot of a subscriber's digest of one cadence sends.
+// It returns nil when the digest is not sent at all.
+func (s *SchedulerService) planDigest(ctx context.Context, group []*model.Subscription, now time.Time) *job {
+	email, frequency := group[0].Email, group[0].Frequency
+	if s.isSuppressed(ctx, email) {
+		logger.Info(ctx, "Address suppressed, skipping digest",
+			slog.String("email", email),
+			slog.String("frequency", frequency))
+		s.finishDeliveries(s.queueDeliveries(ctx, model.DeliveryKindDigest, group...), client.ErrSuppressed)
+		return nil
+	}
+
+	var sections []*model.Subscription
+	for _, sub := range group {
+		// Catch-up summaries are only sent for individual updates; digests simply omit quiet cities
+		if sub.IsPaused(now) || sub.IsQuiet(now) {
+			continue
+		}
+		sections = append(sections, sub)
+	}
+	if len(sections) == 0 {
+		logger.Info(ctx, "All digest subscriptions paused or in quiet hours, skipping digest",
+			slog.String("email", email),
+			slog.String("frequency", frequency))
+		return nil
+	}
+	return &job{kind: jobDigest, subs: group, sections: sections}
+}
+
+// weatherByLocation holds the outcome of fetching the weather of each location of a batch.
+type weatherByLocation map[string]fetchResult
+
+type fetchResult struct {
+	weather *model.WeatherAPIResponse
+	err     error
+}
+
+// of returns the weather fetched for the city, or why there is none.
+func (w weatherByLocation) of(city string) (*model.WeatherAPIResponse, error) {
+	r, ok := w[locationKey(city)]
+	if !ok {
+		return nil, fmt.Errorf("weather for %s was not fetched", city)
+	}
+	return r.weather, r.err
+}
+
+// jobCities lists the locations the jobs need weather for, each once.
+func jobCities(jobs []*job) []string {
+	var subs []*model.Subscription
+	for _, j := range jobs {
+		if j.kind == jobDigest {
+			subs = append(subs, j.sections...)
+		} else {
+			subs = append(subs, j.subs...)
+		}
+	}
+	return uniqueCities(subs)
+}
+
+// uniqueCities lists the locations of the subscriptions, each once in the spelling first seen.
+func uniqueCities(subs []*model.Subscription) []string {
+	var cities []string
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		if key := locationKey(sub.City); !seen[key] {
+			seen[key] = true
+			cities = append(cities, sub.City)
+		}
+	}
+	return cities
+}
+
+// fetchWeather fetches the weather of every city, once per location and at most SCHEDULER_WORKERS at a time.
+func (s *SchedulerService) fetchWeather(ctx context.Context, cities []string) weatherByLocation {
+	metricBatchLocations.Observe(float64(len(cities)))
+
+	var (
+		mu      sync.Mutex
+		weather = make(weatherByLocation, len(cities))
+	)
+	s.fanOut(len(cities), func(i int) {
+		city := cities[i]
+		start := time.Now()
+		w, err := s.weather.GetCurrentWeather(city)
+		metricFetchLatency.Observe(millis(time.Since(start)))
+		if err != nil {
+			metricFetchErrors.Add(1)
+			logger.Error(ctx, fmt.Errorf("failed to fetch weather: %w", err), slog.String("city", city))
+		}
+		mu.Lock()
+		weather[locationKey(city)] = fetchResult{weather: w, err: err}
+		mu.Unlock()
+	})
+	return weather
+}
+
+// fanOut calls fn for every index below n on a pool of SCHEDULER_WORKERS workers and waits for all of them.
+func (s *SchedulerService) fanOut(n int, fn func(i int)) {
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(s.cfg.SchedulerWorkers, 1), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// runJob runs a job for the subscriptions whose lease is still held, which may have been lost while
+// weather was fetched, and moves them to their next slot.
+func (s *SchedulerService) runJob(ctx context.Context, leases *leaseKeeper, j *job, weather weatherByLocation, now time.Time) {
+	var held []*model.Subscription
+	for _, sub := range j.subs {
+		if leases.holds(sub.ID) {
+			held = append(held, sub)
+		}
+	}
+	if len(held) == 0 {
+		return
+	}
+
+	sub := held[0]
+	switch j.kind {
+	case jobQuietSlot:
+		observed, _ := weather.of(sub.City)
+		s.recordQuietSlot(ctx, sub, observed)
+	case jobUpdate:
+		ctx := s.queueDeliveries(ctx, model.DeliveryKindUpdate, sub)
+		s.sendUpdate(ctx, sub, weather)
+		s.observeSendDelay(sub)
+	case jobCatchUp:
+		ctx := s.queueDeliveries(ctx, model.DeliveryKindCatchUp, sub)
+		s.sendCatchUpSummary(ctx, sub, weather, j.observed)
+	case jobDigest:
+		var included []*model.Subscription
+		for _, section := range j.sections {
+			if leases.holds(section.ID) {
+				included = append(included, section)
+			}
+		}
+		if len(included) > 0 {
+			ctx := s.queueDeliveries(ctx, model.DeliveryKindDigest, included...)
+			s.sendDigest(ctx, sub.Email, sub.Frequency, included, weather)
+			s.observeSendDelay(sub)
+		}
+	}
+
+	for _, sub := range held {
+		s.complete(ctx, leases, sub, now)
+	}
+}
+
+// queueDeliveries records in the delivery log that an email is about to be sent for the subscriptions' due
+// slots. The returned context carries the deliveries to the email client, which records the outcome.
+func (s *SchedulerService) queueDeliveries(ctx context.Context, kind string, subs ...*model.Subscription) context.Context {
+	if s.deliveries == nil {
+		return ctx
+	}
+	deliveries := make([]*model.Delivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, &model.Delivery{
+			SubscriptionID: sub.ID,
+			Email:          sub.Email,
+			City:           sub.City,
+			Kind:           kind,
+			ScheduledFor:   *sub.NextRunAt,
+		})
+	}
+	return s.deliveries.Queue(ctx, deliveries...)
+}
+
+// finishDeliveries records the outcome of deliveries that never reached the email client.
+func (s *SchedulerService) finishDeliveries(ctx context.Context, err error) {
+	if s.deliveries != nil {
+		s.deliveries.Finish(ctx, err)
+	}
+}
+
+// complete moves a delivered subscription to its next slot and releases its lease.
+func (s *SchedulerService) complete(ctx context.Context, leases *leaseKeeper, sub *model.Subscription, now time.Time) {
+	leases.release(sub.ID)
+	next := s.nextRun(sub, now)
+	if err := s.repo.CompleteRun(ctx, s.instanceID, sub.ID, next); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Deleted meanwhile, or the lease expired and another instance took the slot over
+			logger.Info(ctx, "Subscription no longer leased, not rescheduled",
+				slog.String("email", sub.Email),
+				slog.String("city", sub.City))
+			return
+		}
+		// The lease expires and another instance picks the slot up again
+		logger.Error(ctx, fmt.Errorf("failed to reschedule subscription: %w", err),
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+	}
+}
+
+// missed reports whether a slot is dropped under the "skip" policy for being later than the grace period.
+// Under "catch-up" an overdue subscription is sent once, however many of its slots were missed.
+func (s *SchedulerService) missed(slot, now time.Time) bool {
+	return s.cfg.SchedulerMissedSlots == config.MissedSlotsSkip && now.Sub(slot) > s.cfg.SchedulerMissedSlotGrace
+}
+
+// isSuppressed reports whether a slot for email should be skipped. Lookup failures are logged and
+// do not skip the slot, since the email client checks the suppression list again before sending.
+func (s *SchedulerService) isSuppressed(ctx context.Context, email string) bool {
+	if s.suppression == nil {
+		return false
+	}
+	suppressed, err := s.suppression.IsSuppressed(ctx, email)
+	if err != nil {
+		logger.Error(ctx, err, slog.String("email", email))
+		return false
+	}
+	return suppressed
+}
+
+// recordQuietSlot adds a skipped slot and the weather observed in it, if any, to the subscription's backlog.
+func (s *SchedulerService) recordQuietSlot(ctx context.Context, sub *model.Subscription, weather *model.WeatherAPIResponse) {
+	backlog := sub.QuietBacklog
+	if backlog == nil {
+		backlog = &model.QuietBacklog{}
+	}
+	backlog.Skipped++
+	if weather != nil {
+		backlog.Observed = append(backlog.Observed, weather)
+	}
+
+	if err := s.repo.SetQuietBacklog(ctx, sub.ID, backlog); err != nil {
+		logger.Error(ctx, fmt.Errorf("failed to store quiet hours backlog: %w", err),
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+	}
+}
+
+func (s *SchedulerService) sendUpdate(ctx context.Context, sub *model.Subscription, weatherByCity weatherByLocation) {
+	weather, err := weatherByCity.of(sub.City)
+	if err != nil {
+		err = fmt.Errorf("weather for %s is unavailable, update not sent: %w", sub.City, err)
+		s.finishDeliveries(ctx, err)
+		s.sendFailed(ctx, err, sub.Email, slog.String("city", sub.City))
+		return
+	}
+
+	logger.Info(ctx, "Attempting to send update",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	if err := client.SendUpdate(ctx, sub, weather, s.updateLinks(sub), s.emailClient); err != nil {
+		s.sendFailed(ctx, err, sub.Email, slog.String("city", sub.City))
+		return
+	}
+	metricEmailsSent.Add(1)
+	logger.Info(ctx, "Weather update sent",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+}
+
+func (s *SchedulerService) sendCatchUpSummary(ctx context.Context, sub *model.Subscription, weatherByCity weatherByLocation, observed []*model.WeatherAPIResponse) {
+	weather, err := weatherByCity.of(sub.City)
+	if err != nil {
+		err = fmt.Errorf("weather for %s is unavailable, catch-up summary not sent: %w", sub.City, err)
+		s.finishDeliveries(ctx, err)
+		s.sendFailed(ctx, err, sub.Email, slog.String("city", sub.City))
+		return
+	}
+
+	logger.Info(ctx, "Attempting to send catch-up summary",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City),
+		slog.Int("observations", len(observed)))
+	if err := client.SendCatchUpSummary(ctx, sub, weather, observed, s.updateLinks(sub), s.emailClient); err != nil {
+		s.sendFailed(ctx, err, sub.Email, slog.String("city", sub.City))
+		return
+	}
+	metricEmailsSent.Add(1)
+	logger.Info(ctx, "Catch-up summary sent",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+}
+
+// sendDigest sends one email covering a subscriber's digest subscriptions that are due with the given cadence.
+// Cities without weather are reported as unavailable; the digest is only dropped if no city has any.
+func (s *SchedulerService) sendDigest(ctx context.Context, email, frequency string, subs []*model.Subscription, weatherByCity weatherByLocation) {
+	var (
+		sections   []client.DigestSection
+		available  int
+		weatherErr error
+	)
+	for _, sub := range subs {
+		weather, err := weatherByCity.of(sub.City)
+		if err != nil {
+			weatherErr = err
+		} else {
+			available++
+		}
+		sections = append(sections, client.DigestSection{Subscription: sub, Weather: weather, Links: s.updateLinks(sub)})
+	}
+	if available == 0 {
+		err := fmt.Errorf("weather is unavailable for every city, digest not sent: %w", weatherErr)
+		s.finishDeliveries(ctx, err)
+		s.sendFailed(ctx, err, email, slog.String("frequency", frequency))
+		return
+	}
+
+	logger.Info(ctx, "Attempting to send digest",
+		slog.String("email", email),
+		slog.String("frequency", frequency),
+		slog.Int("cities", len(sections)))
+	unsubscribeAllToken := s.tokens.Sign(token.PurposeUnsubscribeAll, email)
+	unsubscribeAll := config.BuildUnsubscribeURL(s.cfg.BaseURL, unsubscribeAllToken)
+	oneClick := config.BuildOneClickUnsubscribeURL(s.cfg.BaseURL, unsubscribeAllToken)
+	if err := client.SendDigest(ctx, email, sections, unsubscribeAll, oneClick, s.emailClient); err != nil {
+		s.sendFailed(ctx, err, email, slog.String("frequency", frequency))
+		return
+	}
+	metricEmailsSent.Add(1)
+	logger.Info(ctx, "Weather digest sent",
+		slog.String("email", email),
+		slog.String("frequency", frequency))
+}
+
+func (s *SchedulerService) sendFailed(ctx context.Context, err error, email string, attr slog.Attr) {
+	metricEmailsFailed.Add(1)
+	logger.Error(ctx, err, slog.String("email", email), attr)
+}
@@ -0,0 +1,156 @@
+package scheduler_service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// newInstanceID names this scheduler instance in the leases it takes.
+func newInstanceID(cfg *config.Config) string {
+	if cfg.SchedulerInstanceID != "" {
+		return cfg.SchedulerInstanceID
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "scheduler"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// leaseKeeper renews the claims of a batch while it is being delivered and tracks which of them are
+// still held, so nothing is sent for a subscription or retry another instance has taken over.
+type leaseKeeper struct {
+	s       *SchedulerService
+	what    string
+	renew   func(ctx context.Context, until time.Time) ([]string, error)
+	mu      sync.Mutex
+	held    map[string]bool
+	expires time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// keepLeases keeps the leases of a claimed batch of subscriptions, keyed by subscription ID.
+func (s *SchedulerService) keepLeases(ctx context.Context, subs []*model.Subscription, expires time.Time) *leaseKeeper {
+	ids := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.ID)
+	}
+	return s.keepClaims(ctx, "scheduler leases", ids, expires, func(ctx context.Context, until time.Time) ([]string, error) {
+		return s.repo.RenewLeases(ctx, s.instanceID, until)
+	})
+}
+
+// keepRetryClaims keeps the claims of a batch of retries, keyed by retryKey.
+func (s *SchedulerService) keepRetryClaims(ctx context.Context, retries []*model.Delivery, expires time.Time) *leaseKeeper {
+	ids := make([]string, 0, len(retries))
+	for _, d := range retries {
+		ids = append(ids, retryKey(d.ID))
+	}
+	return s.keepClaims(ctx, "delivery retry claims", ids, expires, func(ctx context.Context, until time.Time) ([]string, error) {
+		renewed, err := s.deliveries.RenewRetryClaims(ctx, s.instanceID, until)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(renewed))
+		for _, id := range renewed {
+			ids = append(ids, retryKey(id))
+		}
+		return ids, nil
+	})
+}
+
+func retryKey(deliveryID int64) string {
+	return strconv.FormatInt(deliveryID, 10)
+}
+
+func (s *SchedulerService) keepClaims(ctx context.Context, what string, ids []string, expires time.Time, renew func(ctx context.Context, until time.Time) ([]string, error)) *leaseKeeper {
+	held := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		held[id] = true
+	}
+
+	rctx, cancel := context.WithCancel(ctx)
+	k := &leaseKeeper{s: s, what: what, renew: renew, held: held, expires: expires, cancel: cancel, done: make(chan struct{})}
+	go k.run(rctx)
+	return k
+}
+
+// run renews the claims three times per SCHEDULER_LEASE_TTL until the batch is done.
+func (k *leaseKeeper) run(ctx context.Context) {
+	defer close(k.done)
+
+	ticker := k.s.clock.NewTicker(k.s.cfg.SchedulerLeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		until := k.s.clock.Now().Add(k.s.cfg.SchedulerLeaseTTL)
+		ids, err := k.renew(ctx, until)
+		if err != nil {
+			if ctx.Err() == nil {
+				// The claims stay usable until the previous expiry; after that they count as lost
+				logger.Error(ctx, fmt.Errorf("failed to renew %s: %w", k.what, err))
+			}
+			continue
+		}
+
+		renewed := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			renewed[id] = true
+		}
+		lost := 0
+		k.mu.Lock()
+		for id := range k.held {
+			if !renewed[id] {
+				delete(k.held, id)
+				lost++
+			}
+		}
+		k.expires = until
+		k.mu.Unlock()
+
+		if lost > 0 {
+			logger.Info(ctx, "Claims lost to another instance",
+				slog.String("claims", k.what),
+				slog.String("instance", k.s.instanceID),
+				slog.Int("lost", lost))
+		}
+	}
+}
+
+// holds reports whether the claim is still held and unexpired.
+func (k *leaseKeeper) holds(id string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.held[id] && k.s.clock.Now().Before(k.expires)
+}
+
+// release stops tracking a claim that is about to be completed.
+func (k *leaseKeeper) release(id string) {
+	k.mu.Lock()
+	delete(k.held, id)
+	k.mu.Unlock()
+}
+
+// close stops renewing; leases not completed by then simply expire.
+func (k *leaseKeeper) close() {
+	k.cancel()
+	<-k.done
+}
@@ -0,0 +1,70 @@
+package scheduler_service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/utils/ratelimit"
+)
+
+// jitter returns how long after its cadence's slot a subscriber is sent to, spreading the subscribers
+// of a slot across SCHEDULER_JITTER. The offset derives from the address alone, so it stays the same
+// from slot to slot and across instances, and every subscription of a digest shares it.
+func (s *SchedulerService) jitter(email string) time.Duration {
+	window := s.cfg.SchedulerJitter.Truncate(time.Second)
+	if window <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return time.Duration(h.Sum64()%uint64(window/time.Second)) * time.Second
+}
+
+// nextRun returns when a subscription is next due: its cadence's first slot after t, plus its jitter.
+func (s *SchedulerService) nextRun(sub *model.Subscription, t time.Time) time.Time {
+	return nextSlot(sub.Frequency, t, s.cfg.DailyStartHour).Add(s.jitter(sub.Email))
+}
+
+// observeSendDelay records how long after its cadence's slot a subscription's email went out,
+// jitter and pacing included. Catch-up summaries are late by design and are not observed.
+func (s *SchedulerService) observeSendDelay(sub *model.Subscription) {
+	if sub.NextRunAt == nil {
+		return
+	}
+	slot := sub.NextRunAt.Add(-s.jitter(sub.Email))
+	metricSendDelay.Observe(millis(s.clock.Now().Sub(slot)))
+}
+
+// pacedClient makes every email wait for its turn under SCHEDULER_SEND_RATE before it is handed
+// to the email client. An email whose context ends while waiting never reaches the client, so its
+// outcome is recorded through refused instead.
+type pacedClient struct {
+	client.Client
+	pacer   *ratelimit.Pacer
+	refused func(ctx context.Context, err error)
+}
+
+// paced limits the emails the scheduler sends to SCHEDULER_SEND_RATE per second, shared by all
+// workers of this instance; replicas do not coordinate, so each one sends at up to that rate.
+// Without a rate the email client is used as it is.
+func (s *SchedulerService) paced(c client.Client) client.Client {
+	if c == nil || s.cfg.SchedulerSendRate <= 0 {
+		return c
+	}
+	return &pacedClient{Client: c, pacer: ratelimit.NewPacer(s.cfg.SchedulerSendRate).WithClock(s.clock), refused: s.finishDeliveries}
+}
+
+func (c *pacedClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	if err := c.pacer.Wait(ctx); err != nil {
+		err = fmt.Errorf("email not sent while waiting for its turn: %w", err)
+		c.refused(ctx, err)
+		return err
+	}
+	return c.Client.SendEmail(ctx, to, subject, body, headers...)
+}
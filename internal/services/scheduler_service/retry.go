@@ -0,0 +1,144 @@
+package scheduler_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+)
+
+// retryJob is one email sent again: a single update, or a digest of the cities that failed together.
+type retryJob struct {
+	deliveries []*model.Delivery
+	subs       []*model.Subscription
+}
+
+// Retry resends the failed deliveries whose backoff has elapsed, claiming SCHEDULER_BATCH_SIZE at a time
+// until none are left or ctx is cancelled, and returns how many were claimed. Retries are sent with the current
+// weather; a catch-up summary is resent as a regular update, since its quiet hours backlog was cleared with the
+// first attempt. A retry the subscriber would not be sent at now is skipped; see retryBlocked.
+func (s *SchedulerService) Retry(ctx context.Context, now time.Time) (int, error) {
+	if s.deliveries == nil {
+		return 0, nil
+	}
+
+	claimed := 0
+	for ctx.Err() == nil {
+		leaseUntil := s.clock.Now().Add(s.cfg.SchedulerLeaseTTL)
+		retries, err := s.deliveries.ClaimRetries(ctx, s.instanceID, now, leaseUntil, s.cfg.SchedulerBatchSize)
+		if err != nil {
+			return claimed, err
+		}
+		if len(retries) == 0 {
+			return claimed, nil
+		}
+		claimed += len(retries)
+		s.retry(ctx, retries, now, leaseUntil)
+	}
+	return claimed, nil
+}
+
+// retryBlocked returns why a retry may no longer be sent at now, or "" if it may: the subscription is paused
+// or in quiet hours, as a regular slot would be, or it switched to or from digest mode since the first attempt,
+// so its next regular email covers it the new way. On-demand updates ignore digest mode, as when first sent.
+func retryBlocked(d *model.Delivery, sub *model.Subscription, now time.Time) string {
+	switch {
+	case sub.IsPaused(now):
+		return "subscription paused"
+	case sub.IsQuiet(now):
+		return "quiet hours"
+	case d.Kind != model.DeliveryKindOnDemand && sub.Digest != (d.Kind == model.DeliveryKindDigest):
+		return "digest mode changed"
+	}
+	return ""
+}
+
+// retry resends claimed retries; like a batch of due slots, they are sent in full once claimed. The claims
+// are renewed meanwhile, and a retry whose claim was lost to another instance is not sent.
+func (s *SchedulerService) retry(ctx context.Context, retries []*model.Delivery, now, leaseUntil time.Time) {
+	ctx = context.WithoutCancel(ctx)
+	claims := s.keepRetryClaims(ctx, retries, leaseUntil)
+	defer claims.close()
+
+	var (
+		jobs    []*retryJob
+		subs    []*model.Subscription
+		digests = make(map[string]*retryJob)
+	)
+	for _, d := range retries {
+		sub, err := s.repo.GetByID(ctx, d.SubscriptionID)
+		if err != nil {
+			// A deleted subscription takes its deliveries with it; on other errors the claim expires
+			// and the retry is picked up again
+			if !errors.Is(err, repository.ErrNotFound) {
+				logger.Error(ctx, fmt.Errorf("failed to load subscription for retry: %w", err),
+					slog.Int64("delivery_id", d.ID))
+			}
+			continue
+		}
+		if reason := retryBlocked(d, sub, now); reason != "" {
+			logger.Info(ctx, "Retry no longer wanted, skipping",
+				slog.String("email", sub.Email),
+				slog.String("city", sub.City),
+				slog.String("reason", reason))
+			s.finishDeliveries(s.deliveries.Resume(ctx, d), fmt.Errorf("%w: %s", client.ErrSkipped, reason))
+			claims.release(retryKey(d.ID))
+			continue
+		}
+		subs = append(subs, sub)
+
+		if d.Kind != model.DeliveryKindDigest {
+			jobs = append(jobs, &retryJob{deliveries: []*model.Delivery{d}, subs: []*model.Subscription{sub}})
+			continue
+		}
+		key := makeDigestKey(d.Email, sub.Frequency) + "|" + d.ScheduledFor.UTC().Format(time.RFC3339)
+		j, ok := digests[key]
+		if !ok {
+			j = &retryJob{}
+			digests[key] = j
+			jobs = append(jobs, j)
+		}
+		j.deliveries = append(j.deliveries, d)
+		j.subs = append(j.subs, sub)
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	weather := s.fetchWeather(ctx, uniqueCities(subs))
+	s.fanOut(len(jobs), func(i int) {
+		j := jobs[i]
+		sub := j.subs[0]
+		for _, d := range j.deliveries {
+			if !claims.holds(retryKey(d.ID)) {
+				logger.Info(ctx, "Retry claimed by another instance, not resending",
+					slog.String("email", sub.Email),
+					slog.Int64("delivery_id", d.ID))
+				return
+			}
+		}
+		defer func() {
+			for _, d := range j.deliveries {
+				claims.release(retryKey(d.ID))
+			}
+		}()
+
+		ctx := s.deliveries.Resume(ctx, j.deliveries...)
+		logger.Info(ctx, "Retrying delivery",
+			slog.String("email", sub.Email),
+			slog.String("kind", j.deliveries[0].Kind),
+			slog.Int("attempt", j.deliveries[0].Attempts+1))
+
+		if j.deliveries[0].Kind == model.DeliveryKindDigest {
+			s.sendDigest(ctx, sub.Email, sub.Frequency, j.subs, weather)
+		} else {
+			s.sendUpdate(ctx, sub, weather)
+		}
+	})
+}
@@ -2,10 +2,10 @@ package scheduler_service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
-	"sync"
 	"time"
 
 	"Weather-API-Application/internal/client"
@@ -13,121 +13,273 @@ import (
 	"Weather-API-Application/internal/logger"
 	"Weather-API-Application/internal/model"
 	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/clock"
+	"Weather-API-Application/internal/utils/token"
 )
 
-// SchedulerService manages background weather update routines for confirmed subscriptions.
+var ErrNotConfirmed = errors.New("subscription is not confirmed")
+
+// SuppressionChecker reports whether an address must not receive email.
+type SuppressionChecker interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+}
+
+// DeliveryRecorder keeps the delivery log. Queue and Resume return a context that carries deliveries
+// to the email client, which records the outcome of the send; Finish records an outcome directly.
+// ClaimRetries hands out failed deliveries whose retry is due, claimed for owner until leaseUntil;
+// RenewRetryClaims extends those claims and returns the ids still held.
+type DeliveryRecorder interface {
+	Queue(ctx context.Context, deliveries ...*model.Delivery) context.Context
+	Resume(ctx context.Context, deliveries ...*model.Delivery) context.Context
+	Finish(ctx context.Context, err error)
+	ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error)
+	RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error)
+}
+
+// SchedulerService delivers weather updates for confirmed subscriptions from a schedule kept in the database.
+// A polling dispatcher leases the subscriptions whose slot is due, sends them and moves them to their next slot,
+// so schedules survive restarts. Hourly slots fall on the hour (UTC), daily slots at DAILY_START_HOUR local time,
+// each subscriber offset within SCHEDULER_JITTER; sends are paced to SCHEDULER_SEND_RATE.
+// Subscriptions in digest mode due in the same slot are sent together, one email per subscriber and cadence.
+// The weather of each location in a claimed batch is fetched once and shared by every email that needs it.
+//
+// Any number of instances can share the database: a leased subscription is invisible to other instances
+// until its lease is released or expires, so each slot is sent once unless an instance dies between
+// sending and recording the send, in which case the instance taking over sends it again. An operator
+// can pause all of them at once; see WithControl.
 type SchedulerService struct {
 	repo        repository.SubscriptionRepository
 	emailClient client.Client
 	cfg         *config.Config
-	mu          sync.Mutex
-	routines    map[string]context.CancelFunc
+	tokens      *token.Hasher
+	weather     client.WeatherClient
+	suppression SuppressionChecker
+	deliveries  DeliveryRecorder
+	control     repository.SchedulerRepository
+	instanceID  string
+	clock       clock.Clock
+	wake        chan struct{}
+	stopped     chan struct{}
 }
 
 func NewSchedulerService(repo repository.SubscriptionRepository, emailClient client.Client, cfg *config.Config) *SchedulerService {
-	return &SchedulerService{
-		repo:        repo,
-		emailClient: emailClient,
-		cfg:         cfg,
-		routines:    make(map[string]context.CancelFunc),
+	s := &SchedulerService{
+		repo:       repo,
+		cfg:        cfg,
+		tokens:     token.NewHasher(cfg.TokenSecret),
+		weather:    client.NewWeatherClient(cfg.WeatherApiKey),
+		instanceID: newInstanceID(cfg),
+		clock:      clock.Real(),
+		wake:       make(chan struct{}, 1),
 	}
+	s.emailClient = s.paced(emailClient)
+	return s
 }
 
-// makeKey builds a unique key for a subscription.
-func makeKey(sub *model.Subscription) string {
-	return fmt.Sprintf("%s|%s", sub.Email, strings.ToLower(sub.City))
+// InstanceID returns the name under which this instance leases subscriptions.
+func (s *SchedulerService) InstanceID() string {
+	return s.instanceID
 }
 
-// StartScheduler starts routines for all confirmed subscriptions.
-func (s *SchedulerService) StartScheduler(ctx context.Context) error {
-	subs, err := s.repo.ListConfirmed(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch confirmed subscriptions: %w", err)
-	}
+// WithWeatherClient replaces the WeatherAPI.com client used to fetch the weather that is sent.
+func (s *SchedulerService) WithWeatherClient(weather client.WeatherClient) *SchedulerService {
+	s.weather = weather
+	return s
+}
 
-	for _, sub := range subs {
-		s.StartFor(ctx, sub)
+// WithClock replaces the clock that decides when slots are due and paces polling, lease renewal and sends.
+func (s *SchedulerService) WithClock(c clock.Clock) *SchedulerService {
+	s.clock = c
+	if paced, ok := s.emailClient.(*pacedClient); ok {
+		paced.pacer.WithClock(c)
 	}
-
-	logger.Info(ctx, "Starting subscription routines",
-		slog.Int("count", len(subs)))
-	return nil
+	return s
 }
 
-// StartFor starts a routine for a single subscription.
-func (s *SchedulerService) StartFor(ctx context.Context, sub *model.Subscription) {
-	subCtx, cancel := context.WithCancel(ctx)
-	key := makeKey(sub)
+// WithSuppressions makes the dispatcher skip slots for suppressed addresses without fetching weather.
+// Sending is refused for them by the email client either way; this only saves the work.
+func (s *SchedulerService) WithSuppressions(checker SuppressionChecker) *SchedulerService {
+	s.suppression = checker
+	return s
+}
 
-	s.mu.Lock()
-	s.routines[key] = cancel
-	s.mu.Unlock()
+// WithDeliveries records every email the scheduler sends, and every slot it skips for a suppressed
+// address, in the delivery log.
+func (s *SchedulerService) WithDeliveries(recorder DeliveryRecorder) *SchedulerService {
+	s.deliveries = recorder
+	return s
+}
 
-	go s.StartRoutine(subCtx, sub)
-	logger.Info(ctx, "Routine started", slog.String("email", sub.Email), slog.String("city", sub.City))
+// makeDigestKey builds a unique key for a subscriber's digest of one cadence.
+func makeDigestKey(email, frequency string) string {
+	return fmt.Sprintf("digest|%s|%s", email, strings.ToLower(frequency))
 }
 
-// StopFor stops a routine for a single subscription if running.
-func (s *SchedulerService) StopFor(sub *model.Subscription) {
-	key := makeKey(sub)
-	s.mu.Lock()
-	if cancel, ok := s.routines[key]; ok {
-		cancel()
-		delete(s.routines, key)
+// nextSlot returns the first delivery slot of the cadence strictly after t.
+func nextSlot(frequency string, t time.Time, dailyStartHour int) time.Time {
+	if strings.ToLower(frequency) != "daily" {
+		return t.Truncate(time.Hour).Add(time.Hour)
 	}
-	s.mu.Unlock()
-}
-
-// StartRoutine runs periodic updates for a single subscription until the context is cancelled.
-func (s *SchedulerService) StartRoutine(ctx context.Context, sub *model.Subscription) {
-	// Determine interval
-	interval := time.Hour
-	if strings.ToLower(sub.Frequency) == "daily" {
-		interval = 24 * time.Hour
-
-		// Wait until next scheduled daily start
-		now := time.Now()
-		next := time.Date(
-			now.Year(), now.Month(), now.Day(),
-			s.cfg.DailyStartHour, 0, 0, 0,
-			now.Location(),
-		)
-		if now.After(next) {
-			next = next.Add(24 * time.Hour)
-		}
-		select {
-		case <-time.After(time.Until(next)):
-		case <-ctx.Done():
-			logger.Info(ctx, "Routine cancelled before first run",
-				slog.String("email", sub.Email),
-				slog.String("city", sub.City))
-			return
-		}
+
+	next := time.Date(t.Year(), t.Month(), t.Day(), dailyStartHour, 0, 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
 	}
+	return next
+}
+
+// StartScheduler runs the dispatcher in the background until the context is cancelled.
+// The first poll happens right away, so slots missed while no instance was running are handled on startup.
+// Later polls happen every SCHEDULER_POLL_INTERVAL and whenever HandleChange sees a reason to poll earlier.
+// Once cancelled, the dispatcher claims nothing new but finishes the batch it is delivering; see Drain.
+func (s *SchedulerService) StartScheduler(ctx context.Context) {
+	s.stopped = make(chan struct{})
+	go s.run(ctx)
+	logger.Info(ctx, "Scheduler started",
+		slog.String("instance", s.instanceID),
+		slog.Duration("poll_interval", s.cfg.SchedulerPollInterval),
+		slog.String("missed_slots", s.cfg.SchedulerMissedSlots))
+}
 
-	ticker := time.NewTicker(interval)
+func (s *SchedulerService) run(ctx context.Context) {
+	defer close(s.stopped)
+
+	ticker := s.clock.NewTicker(s.cfg.SchedulerPollInterval)
 	defer ticker.Stop()
 
+	wasPaused := false
 	for {
+		paused := s.paused(ctx)
+		if paused != wasPaused {
+			logger.Info(ctx, "Scheduler pause state changed",
+				slog.String("instance", s.instanceID),
+				slog.Bool("paused", paused))
+			wasPaused = paused
+		}
+
+		if !paused {
+			now := s.clock.Now()
+			if _, err := s.Dispatch(ctx, now); err != nil {
+				logger.Error(ctx, err)
+			}
+			if _, err := s.Retry(ctx, now); err != nil {
+				logger.Error(ctx, err)
+			}
+		}
+
 		select {
 		case <-ctx.Done():
-			logger.Info(ctx, "Stopping routine",
-				slog.String("email", sub.Email),
-				slog.String("city", sub.City))
+			logger.Info(ctx, "Stopping scheduler")
 			return
-		case <-ticker.C:
-			logger.Info(ctx, "Attempting to send update",
-				slog.String("email", sub.Email),
-				slog.String("city", sub.City))
-			if err := client.SendUpdate(ctx, s.cfg.WeatherApiKey, sub, s.emailClient); err != nil {
-				logger.Error(ctx, err,
-					slog.String("email", sub.Email),
-					slog.String("city", sub.City))
-			} else {
-				logger.Info(ctx, "Weather update sent",
-					slog.String("email", sub.Email),
-					slog.String("city", sub.City))
-			}
+		case <-ticker.C():
+		case <-s.wake:
+		}
+	}
+}
+
+// Drain waits until the dispatcher, whose context must be cancelled first, has finished the deliveries
+// in flight. If ctx expires first, the unfinished batch is abandoned: its leases expire and another
+// instance, or this one after a restart, sends the slots it did not complete.
+func (s *SchedulerService) Drain(ctx context.Context) error {
+	if s.stopped == nil {
+		return nil
+	}
+	select {
+	case <-s.stopped:
+		logger.Info(ctx, "Scheduler stopped", slog.String("instance", s.instanceID))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("in-flight deliveries not finished: %w", ctx.Err())
+	}
+}
+
+// StartFor schedules a newly confirmed subscription for its next slot. Should that fail, the dispatcher
+// schedules the subscription on its next poll instead, so errors are only logged.
+func (s *SchedulerService) StartFor(ctx context.Context, sub *model.Subscription) {
+	next := s.nextRun(sub, s.clock.Now())
+	if err := s.repo.SetNextRunAt(ctx, sub.ID, next); err != nil {
+		logger.Error(ctx, fmt.Errorf("failed to schedule subscription: %w", err),
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+		return
+	}
+	sub.NextRunAt = &next
+
+	logger.Info(ctx, "Subscription scheduled",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City),
+		slog.Time("next_run_at", next))
+}
+
+// Dispatch sends every delivery due at now, claiming batches of SCHEDULER_BATCH_SIZE until none are left
+// or ctx is cancelled, and returns how many subscriptions were claimed. Each subscription is moved to its
+// next slot right after its delivery, whether or not sending succeeded.
+func (s *SchedulerService) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	claimed := 0
+	for ctx.Err() == nil {
+		leaseUntil := s.clock.Now().Add(s.cfg.SchedulerLeaseTTL)
+		subs, err := s.repo.ClaimDue(ctx, s.instanceID, now, leaseUntil, s.cfg.SchedulerBatchSize)
+		if err != nil {
+			return claimed, fmt.Errorf("failed to claim due subscriptions: %w", err)
 		}
+		if len(subs) == 0 {
+			return claimed, nil
+		}
+		claimed += len(subs)
+		s.deliver(ctx, subs, now, leaseUntil)
+	}
+	return claimed, nil
+}
+
+// updateLinks builds signed management links for the subscription; raw tokens are not kept at rest.
+func (s *SchedulerService) updateLinks(sub *model.Subscription) client.UpdateLinks {
+	unsubscribeToken := s.tokens.Sign(token.PurposeUnsubscribe, sub.ID)
+	links := client.UpdateLinks{
+		Unsubscribe:         config.BuildUnsubscribeURL(s.cfg.BaseURL, unsubscribeToken),
+		OneClickUnsubscribe: config.BuildOneClickUnsubscribeURL(s.cfg.BaseURL, unsubscribeToken),
+	}
+	pauseToken := s.tokens.Sign(token.PurposePause, sub.ID)
+	for _, days := range s.cfg.PauseLinkDays {
+		links.Pause = append(links.Pause, client.PauseLink{
+			Days: days,
+			URL:  config.BuildPauseURL(s.cfg.BaseURL, pauseToken, days),
+		})
+	}
+	return links
+}
+
+// SendNow sends a single-city update for a confirmed subscription immediately, outside its schedule.
+// Pauses, quiet hours and digest mode are not applied; the regular schedule is left untouched.
+// A dry run requested for ctx is not written to the delivery log: a failed delivery would be retried
+// later outside the dry run, emailing the subscriber a preview.
+func (s *SchedulerService) SendNow(ctx context.Context, sub *model.Subscription) error {
+	if !sub.Confirmed {
+		return ErrNotConfirmed
+	}
+
+	logger.Info(ctx, "Attempting to send on-demand update",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	if s.deliveries != nil && !client.IsDryRun(ctx) {
+		ctx = s.deliveries.Queue(ctx, &model.Delivery{
+			SubscriptionID: sub.ID,
+			Email:          sub.Email,
+			City:           sub.City,
+			Kind:           model.DeliveryKindOnDemand,
+			ScheduledFor:   s.clock.Now(),
+		})
 	}
+	weather, err := s.weather.GetCurrentWeather(sub.City)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch weather: %w", err)
+		s.finishDeliveries(ctx, err)
+		return err
+	}
+	if err := client.SendUpdate(ctx, sub, weather, s.updateLinks(sub), s.emailClient); err != nil {
+		return fmt.Errorf("failed to send update: %w", err)
+	}
+	logger.Info(ctx, "On-demand update sent",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	return nil
 }
@@ -0,0 +1,1221 @@
+package scheduler_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailClient struct {
+	mu       sync.Mutex
+	sent     []string
+	subjects []string
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, to)
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func TestNextSlot(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		frequency string
+		after     time.Time
+		want      time.Time
+	}{
+		{
+			name:      "Hourly slot is the next full hour",
+			frequency: "hourly",
+			after:     time.Date(2025, 6, 1, 10, 20, 0, 0, time.UTC),
+			want:      time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Hourly slot exactly on the hour moves to the next one",
+			frequency: "hourly",
+			after:     time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+			want:      time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Daily slot later today",
+			frequency: "daily",
+			after:     time.Date(2025, 6, 1, 6, 30, 0, 0, kyiv),
+			want:      time.Date(2025, 6, 1, 8, 0, 0, 0, kyiv),
+		},
+		{
+			name:      "Daily slot tomorrow once today's has passed",
+			frequency: "Daily",
+			after:     time.Date(2025, 6, 1, 8, 0, 0, 0, kyiv),
+			want:      time.Date(2025, 6, 2, 8, 0, 0, 0, kyiv),
+		},
+		{
+			name:      "Daily slot keeps its local hour across a DST change",
+			frequency: "daily",
+			after:     time.Date(2025, 3, 29, 9, 0, 0, 0, kyiv),
+			want:      time.Date(2025, 3, 30, 8, 0, 0, 0, kyiv),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(nextSlot(tt.frequency, tt.after, 8)), "got %s", nextSlot(tt.frequency, tt.after, 8))
+		})
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	longAgo := now.Add(-3 * time.Hour)
+	pausedUntil := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		policy  string
+		claimed []*model.Subscription
+	}{
+		{
+			name:    "Never scheduled subscriptions only get their first slot",
+			policy:  config.MissedSlotsCatchUp,
+			claimed: []*model.Subscription{{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true}},
+		},
+		{
+			name:    "Slots missed beyond the grace period are skipped under the skip policy",
+			policy:  config.MissedSlotsSkip,
+			claimed: []*model.Subscription{{ID: "2", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &longAgo}},
+		},
+		{
+			name:   "Paused subscriptions and digests are not sent",
+			policy: config.MissedSlotsCatchUp,
+			claimed: []*model.Subscription{
+				{ID: "3", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &longAgo, PausedUntil: &pausedUntil},
+				{ID: "4", Email: "other@example.com", City: "Lviv", Frequency: "hourly", Confirmed: true, Digest: true, NextRunAt: &longAgo, PausedUntil: &pausedUntil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				TokenSecret:              "secret",
+				SchedulerBatchSize:       10,
+				SchedulerMissedSlots:     tt.policy,
+				SchedulerMissedSlotGrace: 10 * time.Minute,
+				SchedulerInstanceID:      "node-a",
+				SchedulerLeaseTTL:        time.Minute,
+			}
+			repo := new(repository.MockSubscriptionRepository)
+			repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(tt.claimed, nil).Once()
+			repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(nil, nil).Once()
+			for _, sub := range tt.claimed {
+				repo.On("CompleteRun", mock.Anything, "node-a", sub.ID, nextSlot(sub.Frequency, now, cfg.DailyStartHour)).Return(nil).Once()
+			}
+			emails := &fakeEmailClient{}
+
+			claimed, err := NewSchedulerService(repo, emails, cfg).Dispatch(context.Background(), now)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.claimed), claimed)
+			assert.Empty(t, emails.sent)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDispatchFetchesEachLocationOnce(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	due := now.Add(-5 * time.Second)
+	claimed := []*model.Subscription{
+		{ID: "1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+		{ID: "2", Email: "b@example.com", City: "kyiv ", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+		{ID: "3", Email: "c@example.com", City: "Lviv", Frequency: "hourly", Confirmed: true, Digest: true, NextRunAt: &due},
+		{ID: "4", Email: "c@example.com", City: "KYIV", Frequency: "hourly", Confirmed: true, Digest: true, NextRunAt: &due},
+		{ID: "5", Email: "d@example.com", City: "Odesa", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+	}
+	cfg := &config.Config{
+		TokenSecret:          "secret",
+		SchedulerBatchSize:   10,
+		SchedulerMissedSlots: config.MissedSlotsCatchUp,
+		SchedulerWorkers:     2,
+		SchedulerInstanceID:  "node-a",
+		SchedulerLeaseTTL:    time.Minute,
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(claimed, nil).Once()
+	repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(nil, nil).Once()
+	for _, sub := range claimed {
+		repo.On("CompleteRun", mock.Anything, "node-a", sub.ID, nextSlot(sub.Frequency, now, cfg.DailyStartHour)).Return(nil).Once()
+	}
+	weather := new(client.MockWeatherClient)
+	weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	weather.On("GetCurrentWeather", "Lviv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	weather.On("GetCurrentWeather", "Odesa").Return(nil, assert.AnError).Once()
+	emails := &fakeEmailClient{}
+
+	n, err := NewSchedulerService(repo, emails, cfg).WithWeatherClient(weather).Dispatch(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, len(claimed), n)
+
+	sort.Strings(emails.sent)
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "c@example.com"}, emails.sent,
+		"Every subscriber of a fetched location gets one email; the digest covers both of its cities")
+	weather.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+type suppressedSet map[string]bool
+
+func (s suppressedSet) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	return s[email], nil
+}
+
+type deliveryKey struct{}
+
+// fakeRecorder keeps the delivery log in memory, keyed by subscription ID. Retry claims are renewed
+// unless lostClaims is set, as if another instance had taken them over.
+type fakeRecorder struct {
+	mu         sync.Mutex
+	queued     map[string]*model.Delivery
+	resumed    map[string]*model.Delivery
+	finished   map[string]error
+	retries    []*model.Delivery
+	claimed    []int64
+	lostClaims bool
+	renewals   int
+}
+
+func newFakeRecorder(retries ...*model.Delivery) *fakeRecorder {
+	return &fakeRecorder{
+		queued:   make(map[string]*model.Delivery),
+		resumed:  make(map[string]*model.Delivery),
+		finished: make(map[string]error),
+		retries:  retries,
+	}
+}
+
+func (r *fakeRecorder) Queue(ctx context.Context, deliveries ...*model.Delivery) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range deliveries {
+		r.queued[d.SubscriptionID] = d
+	}
+	return context.WithValue(ctx, deliveryKey{}, deliveries)
+}
+
+func (r *fakeRecorder) Resume(ctx context.Context, deliveries ...*model.Delivery) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range deliveries {
+		r.resumed[d.SubscriptionID] = d
+	}
+	return context.WithValue(ctx, deliveryKey{}, deliveries)
+}
+
+func (r *fakeRecorder) ClaimRetries(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := min(limit, len(r.retries))
+	claimed := r.retries[:n]
+	r.retries = r.retries[n:]
+	for _, d := range claimed {
+		r.claimed = append(r.claimed, d.ID)
+	}
+	return claimed, nil
+}
+
+func (r *fakeRecorder) RenewRetryClaims(ctx context.Context, owner string, leaseUntil time.Time) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renewals++
+	if r.lostClaims {
+		return nil, nil
+	}
+	return r.claimed, nil
+}
+
+func (r *fakeRecorder) renewalCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.renewals
+}
+
+func (r *fakeRecorder) Finish(ctx context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries, _ := ctx.Value(deliveryKey{}).([]*model.Delivery)
+	for _, d := range deliveries {
+		r.finished[d.SubscriptionID] = err
+	}
+}
+
+func TestDispatchRecordsDeliveries(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	due := now.Add(-5 * time.Second)
+	claimed := []*model.Subscription{
+		{ID: "1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+		{ID: "2", Email: "b@example.com", City: "Odesa", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+		{ID: "3", Email: "blocked@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &due},
+	}
+	cfg := &config.Config{
+		TokenSecret:          "secret",
+		SchedulerBatchSize:   10,
+		SchedulerMissedSlots: config.MissedSlotsCatchUp,
+		SchedulerWorkers:     2,
+		SchedulerInstanceID:  "node-a",
+		SchedulerLeaseTTL:    time.Minute,
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(claimed, nil).Once()
+	repo.On("ClaimDue", mock.Anything, "node-a", now, mock.Anything, 10).Return(nil, nil).Once()
+	repo.On("CompleteRun", mock.Anything, "node-a", mock.Anything, mock.Anything).Return(nil).Times(len(claimed))
+	weather := new(client.MockWeatherClient)
+	weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	weather.On("GetCurrentWeather", "Odesa").Return(nil, assert.AnError).Once()
+	recorder := newFakeRecorder()
+
+	_, err := NewSchedulerService(repo, &fakeEmailClient{}, cfg).
+		WithWeatherClient(weather).
+		WithSuppressions(suppressedSet{"blocked@example.com": true}).
+		WithDeliveries(recorder).
+		Dispatch(context.Background(), now)
+	require.NoError(t, err)
+
+	require.Len(t, recorder.queued, 3, "Every slot that was due is logged")
+	for _, d := range recorder.queued {
+		assert.Equal(t, model.DeliveryKindUpdate, d.Kind)
+		assert.True(t, due.Equal(d.ScheduledFor), "Deliveries are logged against the slot they were due in")
+	}
+	assert.NotContains(t, recorder.finished, "1", "The outcome of a sent email is recorded by the email client")
+	assert.ErrorContains(t, recorder.finished["2"], "weather for Odesa is unavailable")
+	assert.ErrorIs(t, recorder.finished["3"], client.ErrSuppressed)
+}
+
+func TestRetry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	slot := now.Add(-time.Hour)
+	paused := now.Add(24 * time.Hour)
+	subs := []*model.Subscription{
+		{ID: "1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true},
+		{ID: "2", Email: "b@example.com", City: "Odesa", Frequency: "hourly", Confirmed: true},
+		{ID: "3", Email: "c@example.com", City: "Lviv", Frequency: "hourly", Confirmed: true, Digest: true},
+		{ID: "4", Email: "c@example.com", City: "kyiv", Frequency: "hourly", Confirmed: true, Digest: true},
+		{ID: "5", Email: "d@example.com", City: "Kharkiv", Frequency: "hourly", Confirmed: true, PausedUntil: &paused},
+		{ID: "6", Email: "e@example.com", City: "Dnipro", Frequency: "hourly", Confirmed: true, Digest: true},
+		{ID: "7", Email: "c@example.com", City: "Lutsk", Frequency: "hourly", Confirmed: true, Digest: true, PausedUntil: &paused},
+		{ID: "8", Email: "f@example.com", City: "Poltava", Frequency: "hourly", Confirmed: true,
+			QuietHours: &model.QuietHours{Start: "11:00", End: "13:00", Timezone: "UTC"}},
+	}
+	recorder := newFakeRecorder(
+		&model.Delivery{ID: 1, SubscriptionID: "1", Email: "a@example.com", Kind: model.DeliveryKindCatchUp, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 2, SubscriptionID: "2", Email: "b@example.com", Kind: model.DeliveryKindUpdate, ScheduledFor: slot, Attempts: 2},
+		&model.Delivery{ID: 3, SubscriptionID: "3", Email: "c@example.com", Kind: model.DeliveryKindDigest, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 4, SubscriptionID: "4", Email: "c@example.com", Kind: model.DeliveryKindDigest, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 5, SubscriptionID: "9", Email: "gone@example.com", Kind: model.DeliveryKindUpdate, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 6, SubscriptionID: "5", Email: "d@example.com", Kind: model.DeliveryKindUpdate, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 7, SubscriptionID: "6", Email: "e@example.com", Kind: model.DeliveryKindUpdate, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 8, SubscriptionID: "7", Email: "c@example.com", Kind: model.DeliveryKindDigest, ScheduledFor: slot, Attempts: 1},
+		&model.Delivery{ID: 9, SubscriptionID: "8", Email: "f@example.com", Kind: model.DeliveryKindUpdate, ScheduledFor: slot, Attempts: 1},
+	)
+	cfg := &config.Config{
+		TokenSecret:        "secret",
+		SchedulerBatchSize: 10,
+		SchedulerWorkers:   2,
+		SchedulerLeaseTTL:  time.Minute,
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	for _, sub := range subs {
+		repo.On("GetByID", mock.Anything, sub.ID).Return(sub, nil).Once()
+	}
+	repo.On("GetByID", mock.Anything, "9").Return(nil, repository.ErrNotFound).Once()
+	weather := new(client.MockWeatherClient)
+	weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	weather.On("GetCurrentWeather", "Lviv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	weather.On("GetCurrentWeather", "Odesa").Return(nil, assert.AnError).Once()
+	emails := &fakeEmailClient{}
+
+	n, err := NewSchedulerService(repo, emails, cfg).
+		WithWeatherClient(weather).
+		WithDeliveries(recorder).
+		Retry(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 9, n)
+
+	sort.Strings(emails.sent)
+	assert.Equal(t, []string{"a@example.com", "c@example.com"}, emails.sent,
+		"A catch-up is resent as an update and the failed digest is resent as one email")
+	assert.Len(t, recorder.resumed, 8, "Retries are recorded against the deliveries they resend or skip")
+	assert.NotContains(t, recorder.resumed, "9")
+	assert.ErrorContains(t, recorder.finished["2"], "weather for Odesa is unavailable")
+	assert.ErrorIs(t, recorder.finished["5"], client.ErrSkipped, "A subscription paused since the first attempt is not resent")
+	assert.ErrorIs(t, recorder.finished["6"], client.ErrSkipped, "A subscription switched to digest mode is not resent alone")
+	assert.ErrorIs(t, recorder.finished["7"], client.ErrSkipped, "A paused city is left out of the digest")
+	assert.ErrorIs(t, recorder.finished["8"], client.ErrSkipped, "Nothing is resent in quiet hours")
+	repo.AssertExpectations(t)
+	weather.AssertExpectations(t)
+}
+
+func TestSendNowDeliveries(t *testing.T) {
+	sub := &model.Subscription{ID: "1", Email: "user@example.com", City: "Kyiv", Confirmed: true}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantQueued bool
+	}{
+		{name: "An on-demand update is logged", wantQueued: true},
+		{name: "A dry run is not logged, so it is never retried outside the dry run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := new(client.MockWeatherClient)
+			weather.On("GetCurrentWeather", "Kyiv").Return(nil, assert.AnError).Once()
+			recorder := newFakeRecorder()
+			emails := &fakeEmailClient{}
+
+			ctx := context.Background()
+			if tt.dryRun {
+				ctx = client.WithDryRun(ctx)
+			}
+			err := NewSchedulerService(nil, emails, &config.Config{TokenSecret: "secret"}).
+				WithWeatherClient(weather).
+				WithDeliveries(recorder).
+				SendNow(ctx, sub)
+			require.ErrorIs(t, err, assert.AnError)
+
+			assert.Empty(t, emails.sent)
+			if tt.wantQueued {
+				assert.Contains(t, recorder.queued, sub.ID)
+				assert.ErrorIs(t, recorder.finished[sub.ID], assert.AnError)
+			} else {
+				assert.Empty(t, recorder.queued)
+				assert.Empty(t, recorder.finished)
+			}
+		})
+	}
+}
+
+func TestRetryClaims(t *testing.T) {
+	tests := []struct {
+		name     string
+		lost     bool
+		wantSent bool
+	}{
+		{name: "Claims are renewed while a retry waits to be sent", wantSent: true},
+		{name: "A retry whose claim was taken over is not sent", lost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+			sub := &model.Subscription{ID: "1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true}
+			recorder := newFakeRecorder(&model.Delivery{ID: 1, SubscriptionID: "1", Email: sub.Email, Kind: model.DeliveryKindUpdate, ScheduledFor: now.Add(-time.Hour), Attempts: 1})
+			recorder.lostClaims = tt.lost
+			cfg := newClockedConfig(config.MissedSlotsCatchUp)
+			c := clock.NewFake(now)
+
+			repo := new(repository.MockSubscriptionRepository)
+			repo.On("GetByID", mock.Anything, "1").Return(sub, nil).Once()
+			// Fetching the weather outlasts the claim twice over
+			weather := new(client.MockWeatherClient)
+			weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Once().Run(func(mock.Arguments) {
+				require.Eventually(t, func() bool { return c.Tickers() == 1 }, time.Second, time.Millisecond)
+				for i := 1; i <= 6; i++ {
+					c.Advance(cfg.SchedulerLeaseTTL / 3)
+					require.Eventually(t, func() bool { return recorder.renewalCount() >= i }, time.Second, time.Millisecond)
+				}
+			})
+			emails := &fakeEmailClient{}
+
+			_, err := NewSchedulerService(repo, emails, cfg).
+				WithClock(c).
+				WithWeatherClient(weather).
+				WithDeliveries(recorder).
+				Retry(context.Background(), now)
+			require.NoError(t, err)
+
+			if tt.wantSent {
+				assert.Equal(t, []string{sub.Email}, emails.sent)
+				assert.Contains(t, recorder.resumed, "1")
+			} else {
+				assert.Empty(t, emails.sent)
+				assert.Empty(t, recorder.resumed)
+				assert.Empty(t, recorder.finished, "The outcome is left to the instance that holds the claim")
+			}
+		})
+	}
+}
+
+// leaseRepo keeps subscriptions in memory and claims them by the same lease rules as the Postgres
+// repository, so several schedulers can share it the way replicas share the database.
+type leaseRepo struct {
+	*repository.MockSubscriptionRepository
+
+	mu        sync.Mutex
+	subs      map[string]*model.Subscription
+	owners    map[string]string
+	expires   map[string]time.Time
+	completed map[string]int
+	backlogs  []*model.QuietBacklog
+}
+
+func newLeaseRepo(subs []*model.Subscription) *leaseRepo {
+	r := &leaseRepo{
+		MockSubscriptionRepository: new(repository.MockSubscriptionRepository),
+		subs:                       make(map[string]*model.Subscription),
+		owners:                     make(map[string]string),
+		expires:                    make(map[string]time.Time),
+		completed:                  make(map[string]int),
+	}
+	for _, sub := range subs {
+		r.subs[sub.ID] = sub
+	}
+	return r
+}
+
+func (r *leaseRepo) ClaimDue(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.subs))
+	for id := range r.subs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var claimed []*model.Subscription
+	for _, id := range ids {
+		sub := r.subs[id]
+		due := sub.NextRunAt == nil || !sub.NextRunAt.After(now)
+		leased := r.owners[id] != "" && !r.expires[id].Before(now)
+		if !due || leased {
+			continue
+		}
+		r.owners[id], r.expires[id] = owner, leaseUntil
+		copied := *sub
+		claimed = append(claimed, &copied)
+		if len(claimed) == limit {
+			break
+		}
+	}
+	return claimed, nil
+}
+
+func (r *leaseRepo) RenewLeases(ctx context.Context, owner string, leaseUntil time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for id, o := range r.owners {
+		if o == owner {
+			r.expires[id] = leaseUntil
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *leaseRepo) CompleteRun(ctx context.Context, owner, subId string, next time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.owners[subId] != owner {
+		return repository.ErrNotFound
+	}
+	r.subs[subId].NextRunAt = &next
+	delete(r.owners, subId)
+	delete(r.expires, subId)
+	r.completed[subId]++
+	return nil
+}
+
+// SetQuietBacklog stores the backlog, so the next claim sees it, and records a copy of every write.
+func (r *leaseRepo) SetQuietBacklog(ctx context.Context, subId string, backlog *model.QuietBacklog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subs[subId].QuietBacklog = copyBacklog(backlog)
+	r.backlogs = append(r.backlogs, copyBacklog(backlog))
+	return nil
+}
+
+func copyBacklog(backlog *model.QuietBacklog) *model.QuietBacklog {
+	if backlog == nil {
+		return nil
+	}
+	copied := *backlog
+	copied.Observed = append([]*model.WeatherAPIResponse(nil), backlog.Observed...)
+	return &copied
+}
+
+// backlogWrites returns the skipped count of every backlog written, with 0 for a cleared backlog.
+func (r *leaseRepo) backlogWrites() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var writes []int
+	for _, backlog := range r.backlogs {
+		skipped := 0
+		if backlog != nil {
+			skipped = backlog.Skipped
+		}
+		writes = append(writes, skipped)
+	}
+	return writes
+}
+
+func newReplicaConfig(instance string) *config.Config {
+	return &config.Config{
+		TokenSecret:          "secret",
+		SchedulerBatchSize:   3,
+		SchedulerMissedSlots: config.MissedSlotsCatchUp,
+		SchedulerInstanceID:  instance,
+		SchedulerLeaseTTL:    time.Minute,
+	}
+}
+
+func TestReplicasSendEachSlotOnce(t *testing.T) {
+	now := time.Now()
+	slot := now.Add(-time.Minute)
+	// Paused subscriptions go through claiming and completion without fetching weather
+	pausedUntil := now.Add(24 * time.Hour)
+
+	var subs []*model.Subscription
+	for i := range 40 {
+		subs = append(subs, &model.Subscription{
+			ID: string(rune('A' + i)), Email: "user@example.com", City: "Kyiv", Frequency: "hourly",
+			Confirmed: true, NextRunAt: &slot, PausedUntil: &pausedUntil,
+		})
+	}
+	repo := newLeaseRepo(subs)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for _, instance := range []string{"node-a", "node-b", "node-c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := NewSchedulerService(repo, &fakeEmailClient{}, newReplicaConfig(instance)).Dispatch(context.Background(), now)
+			assert.NoError(t, err)
+			mu.Lock()
+			total += claimed
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, len(subs), total)
+	for _, sub := range subs {
+		assert.Equal(t, 1, repo.completed[sub.ID], "subscription %s", sub.ID)
+		assert.True(t, repo.subs[sub.ID].NextRunAt.After(now))
+	}
+}
+
+func TestReplicaTakesOverExpiredLease(t *testing.T) {
+	now := time.Now()
+	slot := now.Add(-time.Minute)
+	pausedUntil := now.Add(24 * time.Hour)
+	repo := newLeaseRepo([]*model.Subscription{{
+		ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly",
+		Confirmed: true, NextRunAt: &slot, PausedUntil: &pausedUntil,
+	}})
+
+	// node-a claims the subscription and dies before completing it
+	claimed, err := repo.ClaimDue(context.Background(), "node-a", now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	nodeB := NewSchedulerService(repo, &fakeEmailClient{}, newReplicaConfig("node-b"))
+	taken, err := nodeB.Dispatch(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, taken, "A live lease must not be taken over")
+
+	taken, err = nodeB.Dispatch(context.Background(), now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, taken)
+	assert.Equal(t, 1, repo.completed["1"])
+
+	// node-a coming back cannot complete the slot it lost
+	require.ErrorIs(t, repo.CompleteRun(context.Background(), "node-a", "1", now.Add(time.Hour)), repository.ErrNotFound)
+}
+
+// blockingEmailClient holds every send until released.
+type blockingEmailClient struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.started <- struct{}{}
+	<-c.release
+	return ctx.Err()
+}
+
+func TestDrainFinishesInFlightBatch(t *testing.T) {
+	due := time.Now().Add(-time.Minute)
+	sub := &model.Subscription{ID: "1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &due}
+	cfg := &config.Config{
+		TokenSecret:           "secret",
+		SchedulerPollInterval: time.Hour,
+		SchedulerBatchSize:    10,
+		SchedulerMissedSlots:  config.MissedSlotsCatchUp,
+		SchedulerWorkers:      1,
+		SchedulerInstanceID:   "node-a",
+		SchedulerLeaseTTL:     time.Minute,
+	}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("ClaimDue", mock.Anything, "node-a", mock.Anything, mock.Anything, 10).Return([]*model.Subscription{sub}, nil).Once()
+	repo.On("CompleteRun", mock.Anything, "node-a", "1", mock.Anything).Return(nil).Once()
+	weather := new(client.MockWeatherClient)
+	weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Once()
+	emails := &blockingEmailClient{started: make(chan struct{}), release: make(chan struct{})}
+
+	s := NewSchedulerService(repo, emails, cfg).WithWeatherClient(weather)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.StartScheduler(ctx)
+
+	<-emails.started
+	cancel()
+	expired, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	require.ErrorIs(t, s.Drain(expired), context.DeadlineExceeded, "The send is still in flight")
+
+	close(emails.release)
+	require.NoError(t, s.Drain(context.Background()))
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "ClaimDue", 1)
+}
+
+// pollRepo reports every poll of the dispatcher: the claims it made, and the end of the poll, which is
+// the claim that found nothing due.
+type pollRepo struct {
+	*leaseRepo
+
+	mu    sync.Mutex
+	sends []time.Time
+	polls chan time.Time
+}
+
+func newPollRepo(subs []*model.Subscription) *pollRepo {
+	return &pollRepo{leaseRepo: newLeaseRepo(subs), polls: make(chan time.Time)}
+}
+
+func (r *pollRepo) ClaimDue(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*model.Subscription, error) {
+	claimed, err := r.leaseRepo.ClaimDue(ctx, owner, now, leaseUntil, limit)
+	if len(claimed) > 0 {
+		r.mu.Lock()
+		r.sends = append(r.sends, now)
+		r.mu.Unlock()
+	} else {
+		r.polls <- now
+	}
+	return claimed, err
+}
+
+func (r *pollRepo) claims() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Time(nil), r.sends...)
+}
+
+// nextRunAt returns the subscription's stored slot.
+func (r *pollRepo) nextRunAt(subId string) time.Time {
+	r.leaseRepo.mu.Lock()
+	defer r.leaseRepo.mu.Unlock()
+	return *r.subs[subId].NextRunAt
+}
+
+// waitPoll waits for the dispatcher to finish a poll and returns the time it polled at.
+func (r *pollRepo) waitPoll(t *testing.T) time.Time {
+	t.Helper()
+	select {
+	case now := <-r.polls:
+		return now
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Scheduler did not poll")
+		return time.Time{}
+	}
+}
+
+// runUntil advances the fake clock one poll interval at a time until it reaches end, waiting for each poll.
+func (r *pollRepo) runUntil(t *testing.T, c *clock.Fake, interval time.Duration, end time.Time) {
+	t.Helper()
+	for c.Now().Add(interval).Compare(end) <= 0 {
+		c.Advance(interval)
+		r.waitPoll(t)
+	}
+}
+
+func newClockedConfig(policy string) *config.Config {
+	return &config.Config{
+		TokenSecret:              "secret",
+		DailyStartHour:           8,
+		SchedulerPollInterval:    15 * time.Second,
+		SchedulerBatchSize:       10,
+		SchedulerMissedSlots:     policy,
+		SchedulerMissedSlotGrace: 10 * time.Minute,
+		SchedulerWorkers:         2,
+		SchedulerInstanceID:      "node-a",
+		SchedulerLeaseTTL:        time.Minute,
+	}
+}
+
+func newKyivWeather() *client.MockWeatherClient {
+	weather := new(client.MockWeatherClient)
+	weather.On("GetCurrentWeather", "Kyiv").Return(&model.WeatherAPIResponse{}, nil).Maybe()
+	return weather
+}
+
+// startClocked starts a scheduler on the fake clock and waits for its first poll.
+func startClocked(t *testing.T, repo *pollRepo, c *clock.Fake, cfg *config.Config, emails client.Client) (*SchedulerService, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSchedulerService(repo, emails, cfg).WithWeatherClient(newKyivWeather()).WithClock(c)
+	s.StartScheduler(ctx)
+	require.True(t, c.Now().Equal(repo.waitPoll(t)), "The first poll happens on startup")
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, s.Drain(context.Background()))
+	})
+	return s, cancel
+}
+
+func TestSchedulerHourlyCadence(t *testing.T) {
+	start := time.Date(2025, 6, 1, 11, 59, 50, 0, time.UTC)
+	slot := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newPollRepo([]*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot},
+	})
+	c := clock.NewFake(start)
+	cfg := newClockedConfig(config.MissedSlotsCatchUp)
+	emails := &fakeEmailClient{}
+	startClocked(t, repo, c, cfg, emails)
+
+	assert.Empty(t, repo.claims(), "Nothing is due before the slot")
+
+	repo.runUntil(t, c, cfg.SchedulerPollInterval, start.Add(3*time.Hour))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC),
+		time.Date(2025, 6, 1, 13, 0, 5, 0, time.UTC),
+		time.Date(2025, 6, 1, 14, 0, 5, 0, time.UTC),
+	}, repo.claims(), "One send per hour, on the first poll after the hour")
+	assert.Len(t, emails.sent, 3)
+	assert.Equal(t, time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC), repo.nextRunAt("1"))
+}
+
+func TestSchedulerQuietHours(t *testing.T) {
+	tests := []struct {
+		name         string
+		quiet        model.QuietHours
+		start        time.Time
+		end          time.Time
+		wantSubjects []string
+		wantBacklog  []int
+	}{
+		{
+			// 22:00-06:00 in Kyiv is 19:00-03:00 UTC in summer
+			name:         "overnight window with catch-up summary",
+			quiet:        model.QuietHours{Start: "22:00", End: "06:00", Timezone: "Europe/Kyiv", CatchUpSummary: true},
+			start:        time.Date(2025, 6, 1, 18, 59, 50, 0, time.UTC),
+			end:          time.Date(2025, 6, 2, 4, 30, 0, 0, time.UTC),
+			wantSubjects: []string{"Kyiv catch-up summary", "Kyiv forecast"},
+			wantBacklog:  []int{1, 2, 3, 4, 5, 6, 7, 8, 0},
+		},
+		{
+			name:         "overnight window without catch-up summary",
+			quiet:        model.QuietHours{Start: "22:00", End: "06:00", Timezone: "Europe/Kyiv"},
+			start:        time.Date(2025, 6, 1, 18, 59, 50, 0, time.UTC),
+			end:          time.Date(2025, 6, 2, 4, 30, 0, 0, time.UTC),
+			wantSubjects: []string{"Kyiv forecast", "Kyiv forecast"},
+		},
+		{
+			// 13:00-15:00 in New York is 17:00-19:00 UTC in summer
+			name:         "daytime window in another timezone",
+			quiet:        model.QuietHours{Start: "13:00", End: "15:00", Timezone: "America/New_York", CatchUpSummary: true},
+			start:        time.Date(2025, 6, 1, 16, 59, 50, 0, time.UTC),
+			end:          time.Date(2025, 6, 1, 20, 30, 0, 0, time.UTC),
+			wantSubjects: []string{"Kyiv catch-up summary", "Kyiv forecast"},
+			wantBacklog:  []int{1, 2, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := tt.start.Truncate(time.Hour).Add(time.Hour)
+			quiet := tt.quiet
+			repo := newPollRepo([]*model.Subscription{
+				{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot, QuietHours: &quiet},
+			})
+			c := clock.NewFake(tt.start)
+			cfg := newClockedConfig(config.MissedSlotsCatchUp)
+			emails := &fakeEmailClient{}
+			startClocked(t, repo, c, cfg, emails)
+
+			repo.runUntil(t, c, cfg.SchedulerPollInterval, tt.end)
+
+			assert.Len(t, repo.claims(), int(tt.end.Sub(slot)/time.Hour)+1, "Quiet slots are claimed and moved on like any other")
+			emails.mu.Lock()
+			assert.Equal(t, tt.wantSubjects, emails.subjects)
+			emails.mu.Unlock()
+			assert.Equal(t, tt.wantBacklog, repo.backlogWrites())
+			for _, backlog := range repo.backlogs {
+				if backlog != nil {
+					assert.Len(t, backlog.Observed, backlog.Skipped, "The weather of every skipped slot is kept")
+				}
+			}
+			assert.Nil(t, repo.subs["1"].QuietBacklog, "The backlog is cleared once the window ends")
+		})
+	}
+}
+
+func TestSchedulerDailyAlignment(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		days  int
+	}{
+		{
+			name:  "Ordinary days",
+			start: time.Date(2025, 6, 1, 7, 55, 0, 0, kyiv),
+			days:  3,
+		},
+		{
+			name:  "Clocks go forward, the day is 23 hours long",
+			start: time.Date(2025, 3, 29, 7, 55, 0, 0, kyiv),
+			days:  3,
+		},
+		{
+			name:  "Clocks go back, the day is 25 hours long",
+			start: time.Date(2025, 10, 25, 7, 55, 0, 0, kyiv),
+			days:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := time.Date(tt.start.Year(), tt.start.Month(), tt.start.Day(), 8, 0, 0, 0, kyiv)
+			repo := newPollRepo([]*model.Subscription{
+				{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "daily", Confirmed: true, NextRunAt: &slot},
+			})
+			c := clock.NewFake(tt.start)
+			cfg := newClockedConfig(config.MissedSlotsCatchUp)
+			cfg.SchedulerPollInterval = 10 * time.Minute
+			startClocked(t, repo, c, cfg, &fakeEmailClient{})
+
+			repo.runUntil(t, c, cfg.SchedulerPollInterval, tt.start.AddDate(0, 0, tt.days))
+
+			claims := repo.claims()
+			require.Len(t, claims, tt.days, "One send per day")
+			for i, at := range claims {
+				want := time.Date(tt.start.Year(), tt.start.Month(), tt.start.Day()+i, 8, 5, 0, 0, kyiv)
+				assert.True(t, want.Equal(at), "Day %d was sent at %s, want %s", i+1, at.In(kyiv), want)
+			}
+		})
+	}
+}
+
+func TestSchedulerStopsOnCancel(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	slot := time.Date(2025, 6, 1, 13, 0, 0, 0, time.UTC)
+	repo := newPollRepo([]*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot},
+	})
+	c := clock.NewFake(start)
+	s, cancel := startClocked(t, repo, c, newClockedConfig(config.MissedSlotsCatchUp), &fakeEmailClient{})
+
+	cancel()
+	require.NoError(t, s.Drain(context.Background()))
+	assert.Zero(t, c.Tickers(), "The poll ticker is stopped")
+
+	c.Advance(2 * time.Hour)
+	select {
+	case <-repo.polls:
+		assert.Fail(t, "A stopped scheduler must not poll")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, repo.claims())
+}
+
+func TestSchedulerRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		sends    int
+		reason   string
+		upAgain  time.Time
+		nextSlot time.Time
+	}{
+		{
+			name:     "Missed slots are caught up once",
+			policy:   config.MissedSlotsCatchUp,
+			sends:    2,
+			reason:   "The three hours of downtime are made up for with a single email",
+			upAgain:  time.Date(2025, 6, 1, 16, 30, 0, 0, time.UTC),
+			nextSlot: time.Date(2025, 6, 1, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Missed slots are dropped beyond the grace period",
+			policy:   config.MissedSlotsSkip,
+			sends:    1,
+			reason:   "The overdue slot is skipped and the schedule resumes with the next one",
+			upAgain:  time.Date(2025, 6, 1, 16, 30, 0, 0, time.UTC),
+			nextSlot: time.Date(2025, 6, 1, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Slots missed within the grace period are still sent",
+			policy:   config.MissedSlotsSkip,
+			sends:    2,
+			reason:   "A restart shorter than the grace period loses nothing",
+			upAgain:  time.Date(2025, 6, 1, 13, 5, 0, 0, time.UTC),
+			nextSlot: time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+			slot := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+			repo := newPollRepo([]*model.Subscription{
+				{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot},
+			})
+			cfg := newClockedConfig(tt.policy)
+			emails := &fakeEmailClient{}
+
+			c := clock.NewFake(start)
+			first, cancel := startClocked(t, repo, c, cfg, emails)
+			cancel()
+			require.NoError(t, first.Drain(context.Background()))
+
+			// The next instance starts after the downtime, on a clock of its own
+			startClocked(t, repo, clock.NewFake(tt.upAgain), cfg, emails)
+
+			assert.Len(t, emails.sent, tt.sends, tt.reason)
+			assert.Equal(t, tt.nextSlot, repo.nextRunAt("1"))
+		})
+	}
+}
+
+// fakeControl holds the shared scheduler state in memory and reports every time a poll reads it.
+type fakeControl struct {
+	*repository.MockSchedulerRepository
+
+	mu     sync.Mutex
+	paused bool
+	checks chan bool
+}
+
+func (c *fakeControl) GetState(ctx context.Context) (*model.SchedulerState, error) {
+	c.mu.Lock()
+	paused := c.paused
+	c.mu.Unlock()
+	c.checks <- paused
+	return &model.SchedulerState{Paused: paused}, nil
+}
+
+func (c *fakeControl) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.mu.Unlock()
+}
+
+func TestSchedulerPause(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	slot := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newPollRepo([]*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot},
+	})
+	control := &fakeControl{MockSchedulerRepository: new(repository.MockSchedulerRepository), paused: true, checks: make(chan bool)}
+	c := clock.NewFake(start)
+	cfg := newClockedConfig(config.MissedSlotsCatchUp)
+
+	s := NewSchedulerService(repo, &fakeEmailClient{}, cfg).WithWeatherClient(newKyivWeather()).WithClock(c).WithControl(control)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.StartScheduler(ctx)
+	defer func() {
+		cancel()
+		assert.NoError(t, s.Drain(context.Background()))
+	}()
+
+	require.True(t, <-control.checks)
+	c.Advance(cfg.SchedulerPollInterval)
+	require.True(t, <-control.checks)
+	assert.Empty(t, repo.claims(), "A paused scheduler claims nothing, however overdue")
+
+	control.setPaused(false)
+	c.Advance(cfg.SchedulerPollInterval)
+	require.False(t, <-control.checks)
+	repo.waitPoll(t)
+	assert.Equal(t, []time.Time{start.Add(2 * cfg.SchedulerPollInterval)}, repo.claims(), "The overdue slot is sent on the first poll after resuming")
+}
+
+func TestHandleChange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		payload string
+		wakes   bool
+	}{
+		{name: "Reconnect", payload: "", wakes: true},
+		{name: "Scheduler paused", payload: `{"source":"scheduler","op":"update","paused":true}`, wakes: true},
+		{name: "Confirmed and due", payload: `{"source":"subscription","op":"update","id":7,"confirmed":true,"next_run_at":"2025-06-01T12:00:00"}`, wakes: true},
+		{name: "Confirmed and not scheduled yet", payload: `{"source":"subscription","op":"insert","id":7,"confirmed":true,"next_run_at":null}`, wakes: true},
+		{name: "Confirmed and due later", payload: `{"source":"subscription","op":"update","id":7,"confirmed":true,"next_run_at":"2025-06-01T13:00:00.123456"}`, wakes: false},
+		{name: "Pending", payload: `{"source":"subscription","op":"insert","id":7,"confirmed":false,"next_run_at":null}`, wakes: false},
+		{name: "Deleted", payload: `{"source":"subscription","op":"delete","id":7,"confirmed":true,"next_run_at":"2025-06-01T12:00:00"}`, wakes: false},
+		{name: "Malformed", payload: `{"source":`, wakes: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSchedulerService(nil, nil, &config.Config{TokenSecret: "secret"}).WithClock(clock.NewFake(now))
+			s.HandleChange(context.Background(), tt.payload)
+			assert.Equal(t, tt.wakes, len(s.wake) == 1)
+		})
+	}
+}
+
+func TestSchedulerPollsOnChange(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 5, 0, time.UTC)
+	later := time.Date(2025, 6, 1, 13, 0, 0, 0, time.UTC)
+	repo := newPollRepo([]*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &later},
+	})
+	c := clock.NewFake(start)
+	s, _ := startClocked(t, repo, c, newClockedConfig(config.MissedSlotsCatchUp), &fakeEmailClient{})
+
+	// Moved forward by hand, as an operator fixing a schedule would
+	repo.leaseRepo.mu.Lock()
+	due := start.Add(-time.Minute)
+	repo.subs["1"].NextRunAt = &due
+	repo.leaseRepo.mu.Unlock()
+	s.HandleChange(context.Background(), `{"source":"subscription","op":"update","id":1,"confirmed":true,"next_run_at":"2025-06-01T12:00:00"}`)
+
+	assert.True(t, start.Equal(repo.waitPoll(t)), "The change is acted on without waiting for the next tick")
+	assert.Equal(t, []time.Time{start}, repo.claims())
+}
+
+func TestJitter(t *testing.T) {
+	window := 10 * time.Minute
+	s := NewSchedulerService(nil, nil, &config.Config{TokenSecret: "secret", SchedulerJitter: window})
+
+	offsets := make(map[time.Duration]bool)
+	for i := range 50 {
+		email := fmt.Sprintf("user%d@example.com", i)
+		offset := s.jitter(email)
+		assert.GreaterOrEqual(t, offset, time.Duration(0))
+		assert.Less(t, offset, window)
+		assert.Equal(t, offset, offset.Truncate(time.Second), "Offsets are whole seconds")
+		assert.Equal(t, offset, s.jitter(strings.ToUpper(email)), "The offset belongs to the address, whatever its case")
+		offsets[offset] = true
+	}
+	assert.Greater(t, len(offsets), 40, "Subscribers are spread across the window")
+
+	off := NewSchedulerService(nil, nil, &config.Config{TokenSecret: "secret"})
+	assert.Zero(t, off.jitter("user@example.com"), "No jitter unless configured")
+}
+
+func TestSchedulerJitterKeepsSubscriberOffset(t *testing.T) {
+	start := time.Date(2025, 6, 1, 11, 59, 50, 0, time.UTC)
+	slot := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newPollRepo([]*model.Subscription{
+		{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true, NextRunAt: &slot},
+	})
+	c := clock.NewFake(start)
+	cfg := newClockedConfig(config.MissedSlotsCatchUp)
+	cfg.SchedulerJitter = 30 * time.Minute
+	s, _ := startClocked(t, repo, c, cfg, &fakeEmailClient{})
+	offset := s.jitter("user@example.com")
+	require.NotZero(t, offset)
+
+	repo.runUntil(t, c, cfg.SchedulerPollInterval, start.Add(3*time.Hour))
+
+	claims := repo.claims()
+	require.Len(t, claims, 3, "One send per hour")
+	for i, at := range claims[1:] {
+		due := slot.Add(time.Duration(i+1) * time.Hour).Add(offset)
+		assert.False(t, at.Before(due), "Sent at %s, before its offset slot %s", at, due)
+		assert.Less(t, at.Sub(due), cfg.SchedulerPollInterval, "Sent at %s, long after its offset slot %s", at, due)
+	}
+	assert.Equal(t, slot.Add(3*time.Hour).Add(offset), repo.nextRunAt("1"))
+}
+
+func TestPacedSends(t *testing.T) {
+	cfg := newClockedConfig(config.MissedSlotsCatchUp)
+	cfg.SchedulerSendRate = 20
+	emails := &fakeEmailClient{}
+	c := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	s := NewSchedulerService(nil, emails, cfg).WithClock(c)
+
+	sent := func() int {
+		emails.mu.Lock()
+		defer emails.mu.Unlock()
+		return len(emails.sent)
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.emailClient.SendEmail(context.Background(), "user@example.com", "subject", client.Body{Text: "body"}))
+		}()
+	}
+	require.Eventually(t, func() bool { return sent() == 1 && c.Timers() == 4 }, time.Second, time.Millisecond,
+		"The first send goes out right away and the others wait on the scheduler's clock")
+
+	for i := 2; i <= 5; i++ {
+		c.Advance(50 * time.Millisecond)
+		require.Eventually(t, func() bool { return sent() == i }, time.Second, time.Millisecond, "Sends at 20 per second are 50ms apart")
+	}
+	wg.Wait()
+	assert.Equal(t, 5, sent())
+}
+
+func TestPacedSendCancelled(t *testing.T) {
+	cfg := newClockedConfig(config.MissedSlotsCatchUp)
+	cfg.SchedulerSendRate = 0.1
+	emails := &fakeEmailClient{}
+	recorder := newFakeRecorder()
+	s := NewSchedulerService(nil, emails, cfg).WithDeliveries(recorder)
+	sub := &model.Subscription{ID: "1", Email: "user@example.com", City: "Kyiv"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = recorder.Queue(ctx, &model.Delivery{SubscriptionID: sub.ID})
+	require.NoError(t, s.emailClient.SendEmail(ctx, sub.Email, "subject", client.Body{Text: "body"}), "The first send goes out right away")
+
+	cancel()
+	err := s.emailClient.SendEmail(ctx, sub.Email, "subject", client.Body{Text: "body"})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, emails.sent, 1)
+	assert.ErrorIs(t, recorder.finished[sub.ID], context.Canceled, "A send given up while waiting is recorded as failed")
+}
@@ -1,10 +1,33 @@
 package subscription_service
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"Weather-API-Application/internal/repository"
+)
 
 var (
 	ErrSubscriptionExists         = errors.New("subscription already exists")
-	ErrNotFound                   = errors.New("subscription not found")
+	ErrNotFound                   = repository.ErrNotFound
 	ErrAlreadyConfirmed           = errors.New("subscription already confirmed")
+	ErrTokenExpired               = errors.New("confirmation token expired")
 	ErrFailedToCreateSubscription = errors.New("failed to create subscription")
+	ErrQuietHoursRequireHourly    = errors.New("quiet hours are only supported for hourly subscriptions")
+	ErrSubscriptionLimit          = errors.New("too many subscriptions for this email")
+	ErrResendCooldown             = errors.New("confirmation email was sent recently")
 )
+
+// RetryAfterError wraps a refusal that may succeed later; RetryAfter is zero when no time is known.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
@@ -7,19 +7,18 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
-
-	"github.com/google/uuid"
+	"time"
 
 	"Weather-API-Application/internal/client"
 	"Weather-API-Application/internal/config"
 	"Weather-API-Application/internal/logger"
 	"Weather-API-Application/internal/model"
 	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
 )
 
 type Scheduler interface {
 	StartFor(ctx context.Context, sub *model.Subscription)
-	StopFor(sub *model.Subscription)
 }
 
 type SubscriptionService struct {
@@ -27,6 +26,7 @@ type SubscriptionService struct {
 	emailClient client.Client
 	cfg         *config.Config
 	scheduler   Scheduler
+	tokens      *token.Hasher
 	mu          sync.Mutex
 }
 
@@ -35,6 +35,7 @@ func NewSubscriptionService(repo repository.SubscriptionRepository, emailClient
 		repo:        repo,
 		emailClient: emailClient,
 		cfg:         cfg,
+		tokens:      token.NewHasher(cfg.TokenSecret),
 	}
 }
 
@@ -44,28 +45,51 @@ func (s *SubscriptionService) WithScheduler(scheduler Scheduler) *SubscriptionSe
 }
 
 // Subscribe creates a new subscription or updates a pending one and sends a confirmation email.
+// It refuses with ErrSubscriptionLimit when confirmed subscriptions fill the cap of the email, and with a
+// *RetryAfterError when pending ones do or a confirmation for the same city was sent too recently.
 func (s *SubscriptionService) Subscribe(ctx context.Context, req *model.Subscription) error {
-	rowExists, confirmed, err := s.repo.CheckConfirmation(ctx, req)
+	existingSubs, err := s.repo.ListByEmail(ctx, req.Email)
 	if err != nil {
 		return fmt.Errorf("check confirmation: %w", err)
 	}
 
+	var existing *model.Subscription
+	for _, sub := range existingSubs {
+		if sub.City == req.City {
+			existing = sub
+			break
+		}
+	}
+
 	// 1) No subscription -> create and send confirmation email
-	if !rowExists {
-		token := createNewToken()
+	if existing == nil {
+		if err := s.checkSubscriptionLimit(existingSubs); err != nil {
+			logger.Info(ctx, "Subscription limit reached",
+				slog.String("email", req.Email),
+				slog.String("city", req.City))
+			return err
+		}
+
+		confirmToken := token.New()
 		sub := &model.Subscription{
-			Email:     req.Email,
-			City:      req.City,
-			Frequency: req.Frequency,
-			Token:     token,
-			Confirmed: false,
+			Email:        req.Email,
+			City:         req.City,
+			Frequency:    req.Frequency,
+			ConfirmToken: s.tokens.Hash(confirmToken),
+			Confirmed:    false,
+			Digest:       req.Digest,
+			QuietHours:   req.QuietHours,
 		}
 
-		if err := s.repo.Create(ctx, sub); err != nil {
+		// The repository enforces the cap again in the insert transaction, which a concurrent request may win
+		if err := s.repo.Create(ctx, sub, s.cfg.MaxSubscriptionsPerEmail, time.Now().Add(-s.cfg.ConfirmTokenTTL)); err != nil {
+			if errors.Is(err, repository.ErrLimitReached) {
+				return s.limitReached(ctx, req.Email)
+			}
 			return ErrFailedToCreateSubscription
 		}
 
-		if err := s.emailClient.SendEmail(ctx, sub.Email, config.ConfirmSubject, config.BuildConfirmBody(s.cfg.BaseURL, token)); err != nil {
+		if err := client.SendConfirmation(ctx, sub.Email, config.BuildConfirmURL(s.cfg.BaseURL, confirmToken), s.emailClient); err != nil {
 			logger.Error(ctx, err,
 				slog.String("email", sub.Email),
 				slog.String("city", sub.City))
@@ -78,32 +102,97 @@ func (s *SubscriptionService) Subscribe(ctx context.Context, req *model.Subscrip
 	}
 
 	// 2) Exists but not confirmed -> update token and resend confirmation
-	if !confirmed {
-		token := createNewToken()
-		req.Token = token
-		if err := s.repo.UpdateTokenByEmailCity(ctx, req); err != nil {
-			return fmt.Errorf("failed to update subscription token: %w", err)
-		}
-
-		if err := s.emailClient.SendEmail(ctx, req.Email, config.ConfirmSubject, config.BuildConfirmBody(s.cfg.BaseURL, token)); err != nil {
-			logger.Error(ctx, err,
+	if !existing.Confirmed {
+		if wait := time.Until(existing.CreatedAt.Add(s.cfg.ConfirmResendCooldown)); wait > 0 {
+			logger.Info(ctx, "Confirmation resend refused during cooldown",
 				slog.String("email", req.Email),
 				slog.String("city", req.City))
-			return fmt.Errorf("failed to send confirmation email: %w", err)
+			return &RetryAfterError{Err: ErrResendCooldown, RetryAfter: wait}
 		}
-		logger.Info(ctx, "Confirmation email resent",
-			slog.String("email", req.Email),
-			slog.String("city", req.City))
-		return nil
+
+		return s.resendConfirmation(ctx, req)
 	}
 
 	// 3) Exists and confirmed -> business rule: treat as error
 	return ErrSubscriptionExists
 }
 
-// ConfirmSubscription confirms subscription by token.
-func (s *SubscriptionService) ConfirmSubscription(ctx context.Context, token string) (*model.Subscription, error) {
-	subId, sub, err := s.repo.GetByToken(ctx, token)
+// resendConfirmation issues a new confirmation token for a pending subscription, which also restarts
+// its expiry, and emails it. The settings on sub replace the stored ones. A dry run requested for the
+// context leaves the subscription as it is, so the link the subscriber already has keeps working and
+// the captured one does not.
+func (s *SubscriptionService) resendConfirmation(ctx context.Context, sub *model.Subscription) error {
+	confirmToken := token.New()
+	if !client.IsDryRun(ctx) {
+		sub.ConfirmToken = s.tokens.Hash(confirmToken)
+		if err := s.repo.UpdateConfirmTokenByEmailCity(ctx, sub); err != nil {
+			return fmt.Errorf("failed to update subscription token: %w", err)
+		}
+	}
+
+	if err := client.SendConfirmation(ctx, sub.Email, config.BuildConfirmURL(s.cfg.BaseURL, confirmToken), s.emailClient); err != nil {
+		logger.Error(ctx, err,
+			slog.String("email", sub.Email),
+			slog.String("city", sub.City))
+		return fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+	logger.Info(ctx, "Confirmation email resent",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	return nil
+}
+
+// checkSubscriptionLimit counts confirmed subscriptions and pending ones whose confirmation link is still valid.
+// When pending ones hold the email at its cap, it returns a *RetryAfterError, as the caller may retry once the
+// oldest of them expires. When confirmed ones alone fill the cap, waiting does not help and ErrSubscriptionLimit
+// is returned as is.
+func (s *SubscriptionService) checkSubscriptionLimit(subs []*model.Subscription) error {
+	var (
+		confirmed, pending int
+		retryAfter         time.Duration
+	)
+	for _, sub := range subs {
+		if sub.Confirmed {
+			confirmed++
+			continue
+		}
+		expiresIn := time.Until(sub.CreatedAt.Add(s.cfg.ConfirmTokenTTL))
+		if expiresIn <= 0 {
+			continue
+		}
+		pending++
+		if retryAfter == 0 || expiresIn < retryAfter {
+			retryAfter = expiresIn
+		}
+	}
+	switch {
+	case confirmed >= s.cfg.MaxSubscriptionsPerEmail:
+		return ErrSubscriptionLimit
+	case confirmed+pending >= s.cfg.MaxSubscriptionsPerEmail:
+		return &RetryAfterError{Err: ErrSubscriptionLimit, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// limitReached builds the refusal for an insert the repository turned down at the cap, after the subscriptions
+// of the email changed since they were checked.
+func (s *SubscriptionService) limitReached(ctx context.Context, email string) error {
+	logger.Info(ctx, "Subscription limit reached by a concurrent request", slog.String("email", email))
+	subs, err := s.repo.ListByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("check subscription limit: %w", err)
+	}
+	if err := s.checkSubscriptionLimit(subs); err != nil {
+		return err
+	}
+	// The subscriptions filling the cap are gone again, so the client may simply retry
+	return &RetryAfterError{Err: ErrSubscriptionLimit, RetryAfter: time.Second}
+}
+
+// ConfirmSubscription confirms a pending subscription by its confirmation token. Confirmation retires the token,
+// so a link that was already used is reported as ErrNotFound.
+func (s *SubscriptionService) ConfirmSubscription(ctx context.Context, confirmToken string) (*model.Subscription, error) {
+	subId, sub, err := s.repo.GetByConfirmToken(ctx, s.tokens.Hash(confirmToken))
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			return nil, ErrNotFound
@@ -111,27 +200,51 @@ func (s *SubscriptionService) ConfirmSubscription(ctx context.Context, token str
 		return nil, fmt.Errorf("failed to scan subscription: %w", err)
 	}
 
-	if sub.Confirmed {
-		return nil, ErrAlreadyConfirmed
+	if time.Since(sub.CreatedAt) > s.cfg.ConfirmTokenTTL {
+		return nil, ErrTokenExpired
 	}
 
+	if err := s.activate(ctx, subId, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// activate confirms a pending subscription and schedules its deliveries.
+func (s *SubscriptionService) activate(ctx context.Context, subId string, sub *model.Subscription) error {
 	if err := s.repo.SetConfirmed(ctx, subId); err != nil {
-		return nil, fmt.Errorf("failed to update subscription: %w", err)
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	confirmedAt := time.Now()
+	sub.Confirmed = true
+	sub.ConfirmedAt = &confirmedAt
+	sub.ConfirmToken = ""
+
+	// Digest mode is per subscriber: the latest confirmed choice applies to all of their subscriptions.
+	// Slots are aligned per cadence, so the scheduler groups them from their next slot on.
+	if _, err := s.repo.SetDigestByEmail(ctx, sub.Email, sub.Digest); err != nil {
+		return fmt.Errorf("failed to apply digest preference: %w", err)
 	}
 
 	logger.Info(ctx, "Subscription confirmed",
 		slog.String("email", sub.Email),
-		slog.String("city", sub.City))
+		slog.String("city", sub.City),
+		slog.Bool("digest", sub.Digest))
 
 	if s.scheduler != nil {
 		s.scheduler.StartFor(ctx, sub)
 	}
-	return sub, nil
+	return nil
 }
 
-// Unsubscribe removes subscription by token and stops its routine if running.
-func (s *SubscriptionService) Unsubscribe(ctx context.Context, token string) error {
-	_, sub, err := s.repo.GetByToken(ctx, token)
+// Unsubscribe removes subscription by its signed unsubscribe token; deleted subscriptions are never claimed for delivery.
+// The token from digest emails removes all subscriptions of the subscriber.
+func (s *SubscriptionService) Unsubscribe(ctx context.Context, unsubscribeToken string) error {
+	if email, ok := s.tokens.Verify(token.PurposeUnsubscribeAll, unsubscribeToken); ok {
+		return s.unsubscribeAll(ctx, email)
+	}
+
+	sub, err := s.getByUnsubscribeToken(ctx, unsubscribeToken)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			return ErrNotFound
@@ -139,28 +252,210 @@ func (s *SubscriptionService) Unsubscribe(ctx context.Context, token string) err
 		return fmt.Errorf("failed to scan subscription: %w", err)
 	}
 
-	if err := s.repo.DeleteByToken(ctx, token); err != nil {
+	if err := s.repo.DeleteByID(ctx, sub.ID); err != nil {
 		return fmt.Errorf("failed to delete subscription: %w", err)
 	}
 
-	if s.scheduler != nil {
-		s.scheduler.StopFor(sub)
-	}
-
 	logger.Info(ctx, "Subscription unsubscribed",
 		slog.String("email", sub.Email),
 		slog.String("city", sub.City))
 	return nil
 }
 
-func (s *SubscriptionService) fetchConfirmedSubscriptions(ctx context.Context) ([]*model.Subscription, error) {
-	return s.repo.ListConfirmed(ctx)
+func (s *SubscriptionService) unsubscribeAll(ctx context.Context, email string) error {
+	removed, err := s.repo.DeleteByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscriptions: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+
+	logger.Info(ctx, "All subscriptions unsubscribed",
+		slog.String("email", email),
+		slog.Int64("count", removed))
+	return nil
 }
 
-func MakeKey(sub *model.Subscription) string {
-	return fmt.Sprintf("%s|%s", sub.Email, strings.ToLower(sub.City))
+func (s *SubscriptionService) getByUnsubscribeToken(ctx context.Context, unsubscribeToken string) (*model.Subscription, error) {
+	subId, ok := s.tokens.Verify(token.PurposeUnsubscribe, unsubscribeToken)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.repo.GetByID(ctx, subId)
 }
 
-func createNewToken() string {
-	return uuid.New().String()
+// UnsubscribeToken returns the signed token used in unsubscribe links.
+func (s *SubscriptionService) UnsubscribeToken(sub *model.Subscription) string {
+	return s.tokens.Sign(token.PurposeUnsubscribe, sub.ID)
+}
+
+// Pause suspends deliveries for the given number of days. The token is the signed pause token from update emails.
+func (s *SubscriptionService) Pause(ctx context.Context, pauseToken string, days int) (*model.Subscription, error) {
+	sub, err := s.getByPauseToken(ctx, pauseToken)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	if err := s.repo.SetPausedUntil(ctx, sub.ID, &until); err != nil {
+		return nil, fmt.Errorf("failed to pause subscription: %w", err)
+	}
+	sub.PausedUntil = &until
+
+	logger.Info(ctx, "Subscription paused",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City),
+		slog.Time("paused_until", until))
+	return sub, nil
+}
+
+// Resume lifts a pause before it expires.
+func (s *SubscriptionService) Resume(ctx context.Context, pauseToken string) (*model.Subscription, error) {
+	sub, err := s.getByPauseToken(ctx, pauseToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetPausedUntil(ctx, sub.ID, nil); err != nil {
+		return nil, fmt.Errorf("failed to resume subscription: %w", err)
+	}
+	sub.PausedUntil = nil
+
+	logger.Info(ctx, "Subscription resumed",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	return sub, nil
+}
+
+// SetQuietHours replaces the quiet hours of an hourly subscription; nil removes them.
+// The token is the signed management token from update emails.
+func (s *SubscriptionService) SetQuietHours(ctx context.Context, manageToken string, quietHours *model.QuietHours) (*model.Subscription, error) {
+	sub, err := s.getByPauseToken(ctx, manageToken)
+	if err != nil {
+		return nil, err
+	}
+	if quietHours != nil && !strings.EqualFold(sub.Frequency, "hourly") {
+		return nil, ErrQuietHoursRequireHourly
+	}
+
+	if err := s.repo.SetQuietHours(ctx, sub.ID, quietHours); err != nil {
+		return nil, fmt.Errorf("failed to update quiet hours: %w", err)
+	}
+	sub.QuietHours = quietHours
+
+	logger.Info(ctx, "Quiet hours updated",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City),
+		slog.Bool("enabled", quietHours != nil))
+	return sub, nil
+}
+
+// PauseToken returns the signed management token used in pause, resume and settings links.
+func (s *SubscriptionService) PauseToken(sub *model.Subscription) string {
+	return s.tokens.Sign(token.PurposePause, sub.ID)
+}
+
+func (s *SubscriptionService) getByPauseToken(ctx context.Context, pauseToken string) (*model.Subscription, error) {
+	subId, ok := s.tokens.Verify(token.PurposePause, pauseToken)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	sub, err := s.repo.GetByID(ctx, subId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan subscription: %w", err)
+	}
+	if !sub.Confirmed {
+		return nil, ErrNotFound
+	}
+	return sub, nil
+}
+
+// List returns one page of subscriptions matching the filter and the total number of matches.
+func (s *SubscriptionService) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, int, error) {
+	subs, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return subs, total, nil
+}
+
+// Get returns a subscription by its id.
+func (s *SubscriptionService) Get(ctx context.Context, subId string) (*model.Subscription, error) {
+	sub, err := s.repo.GetByID(ctx, subId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// ForceConfirm confirms a pending subscription without its confirmation token, regardless of the token's age.
+func (s *SubscriptionService) ForceConfirm(ctx context.Context, subId string) (*model.Subscription, error) {
+	sub, err := s.Get(ctx, subId)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Confirmed {
+		return nil, ErrAlreadyConfirmed
+	}
+
+	if err := s.activate(ctx, sub.ID, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ResendConfirmation emails a new confirmation link for a pending subscription, bypassing the resend cooldown.
+func (s *SubscriptionService) ResendConfirmation(ctx context.Context, subId string) (*model.Subscription, error) {
+	sub, err := s.Get(ctx, subId)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Confirmed {
+		return nil, ErrAlreadyConfirmed
+	}
+
+	if err := s.resendConfirmation(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Delete removes a subscription by its id, which ends its deliveries.
+func (s *SubscriptionService) Delete(ctx context.Context, subId string) error {
+	sub, err := s.Get(ctx, subId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteByID(ctx, sub.ID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	logger.Info(ctx, "Subscription deleted",
+		slog.String("email", sub.Email),
+		slog.String("city", sub.City))
+	return nil
+}
+
+// HashLegacyTokens rehashes tokens stored in plain text before hashing at rest was introduced,
+// so links that were already sent keep working.
+func (s *SubscriptionService) HashLegacyTokens(ctx context.Context) error {
+	migrated, err := s.repo.HashLegacyTokens(ctx, s.tokens.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to hash legacy tokens: %w", err)
+	}
+	if migrated > 0 {
+		logger.Info(ctx, "Legacy tokens hashed", slog.Int64("count", migrated))
+	}
+	return nil
 }
@@ -0,0 +1,369 @@
+package subscription_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmSubscription(t *testing.T) {
+	cfg := &config.Config{ConfirmTokenTTL: 24 * time.Hour, TokenSecret: "secret"}
+	tokenHash := token.NewHasher(cfg.TokenSecret).Hash("token")
+
+	tests := []struct {
+		name        string
+		mockSetup   func(*repository.MockSubscriptionRepository)
+		expectedErr error
+	}{
+		{
+			name: "Fresh token confirms subscription",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				sub := &model.Subscription{Email: "user@example.com", City: "Kyiv", CreatedAt: time.Now().Add(-time.Hour)}
+				m.On("GetByConfirmToken", mock.Anything, tokenHash).Return("1", sub, nil)
+				m.On("SetConfirmed", mock.Anything, "1").Return(nil)
+				m.On("SetDigestByEmail", mock.Anything, "user@example.com", false).Return(int64(0), nil)
+			},
+		},
+		{
+			name: "Expired token is rejected",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				sub := &model.Subscription{Email: "user@example.com", City: "Kyiv", CreatedAt: time.Now().Add(-25 * time.Hour)}
+				m.On("GetByConfirmToken", mock.Anything, tokenHash).Return("1", sub, nil)
+			},
+			expectedErr: ErrTokenExpired,
+		},
+		{
+			name: "Unknown token is reported as not found",
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("GetByConfirmToken", mock.Anything, tokenHash).Return("", nil, repository.ErrNotFound)
+			},
+			expectedErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			tt.mockSetup(repo)
+
+			svc := NewSubscriptionService(repo, nil, cfg)
+			sub, err := svc.ConfirmSubscription(context.Background(), "token")
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.True(t, sub.Confirmed)
+				require.Empty(t, sub.ConfirmToken)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+type mockScheduler struct {
+	mock.Mock
+}
+
+func (m *mockScheduler) StartFor(ctx context.Context, sub *model.Subscription) {
+	m.Called(ctx, sub)
+}
+
+func TestConfirmSubscriptionDigestMode(t *testing.T) {
+	cfg := &config.Config{ConfirmTokenTTL: 24 * time.Hour, TokenSecret: "secret"}
+	tokenHash := token.NewHasher(cfg.TokenSecret).Hash("token")
+
+	tests := []struct {
+		name      string
+		changed   int64
+		mockSetup func(*repository.MockSubscriptionRepository, *mockScheduler)
+	}{
+		{
+			name:    "Unchanged mode starts only the new subscription",
+			changed: 0,
+			mockSetup: func(r *repository.MockSubscriptionRepository, sch *mockScheduler) {
+				sch.On("StartFor", mock.Anything, mock.MatchedBy(func(sub *model.Subscription) bool { return sub.City == "Kyiv" })).Once()
+			},
+		},
+		{
+			name:    "Switching to digest schedules only the new subscription",
+			changed: 2,
+			mockSetup: func(r *repository.MockSubscriptionRepository, sch *mockScheduler) {
+				// The others keep their slots; the dispatcher groups them into digests from their next slot on
+				sch.On("StartFor", mock.Anything, mock.MatchedBy(func(sub *model.Subscription) bool { return sub.City == "Kyiv" })).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			sch := new(mockScheduler)
+
+			sub := &model.Subscription{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Digest: true, CreatedAt: time.Now()}
+			repo.On("GetByConfirmToken", mock.Anything, tokenHash).Return("1", sub, nil)
+			repo.On("SetConfirmed", mock.Anything, "1", mock.AnythingOfType("string")).Return(nil)
+			repo.On("SetDigestByEmail", mock.Anything, "user@example.com", true).Return(tt.changed, nil)
+			tt.mockSetup(repo, sch)
+
+			svc := NewSubscriptionService(repo, nil, cfg).WithScheduler(sch)
+			_, err := svc.ConfirmSubscription(context.Background(), "token")
+			require.NoError(t, err)
+
+			repo.AssertExpectations(t)
+			sch.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	cfg := &config.Config{TokenSecret: "secret"}
+	hasher := token.NewHasher(cfg.TokenSecret)
+
+	tests := []struct {
+		name        string
+		token       string
+		mockSetup   func(*repository.MockSubscriptionRepository)
+		expectedErr error
+	}{
+		{
+			name:  "Signed token from update email is resolved by subscription id",
+			token: hasher.Sign(token.PurposeUnsubscribe, "7"),
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				sub := &model.Subscription{ID: "7", Email: "user@example.com", City: "Kyiv", Confirmed: true}
+				m.On("GetByID", mock.Anything, "7").Return(sub, nil)
+				m.On("DeleteByID", mock.Anything, "7").Return(nil)
+			},
+		},
+		{
+			name:  "Signed token from digest email removes all subscriptions of the subscriber",
+			token: hasher.Sign(token.PurposeUnsubscribeAll, "user@example.com"),
+			mockSetup: func(m *repository.MockSubscriptionRepository) {
+				m.On("DeleteByEmail", mock.Anything, "user@example.com").Return(int64(3), nil)
+			},
+		},
+		{
+			name:        "Unsigned token is reported as not found",
+			token:       "unknown",
+			mockSetup:   func(m *repository.MockSubscriptionRepository) {},
+			expectedErr: ErrNotFound,
+		},
+		{
+			name:        "Pause token does not unsubscribe",
+			token:       hasher.Sign(token.PurposePause, "7"),
+			mockSetup:   func(m *repository.MockSubscriptionRepository) {},
+			expectedErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			tt.mockSetup(repo)
+
+			svc := NewSubscriptionService(repo, nil, cfg)
+			err := svc.Unsubscribe(context.Background(), tt.token)
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+			}
+			repo.AssertNotCalled(t, "GetByConfirmToken", mock.Anything, mock.Anything)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	cfg := &config.Config{TokenSecret: "secret"}
+	hasher := token.NewHasher(cfg.TokenSecret)
+	pauseToken := hasher.Sign(token.PurposePause, "7")
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("GetByID", mock.Anything, "7").Return(&model.Subscription{ID: "7", Email: "user@example.com", City: "Kyiv", Confirmed: true}, nil)
+	repo.On("SetPausedUntil", mock.Anything, "7", mock.MatchedBy(func(until *time.Time) bool {
+		return until != nil && until.Sub(time.Now()) > 13*24*time.Hour
+	})).Return(nil).Once()
+	repo.On("SetPausedUntil", mock.Anything, "7", (*time.Time)(nil)).Return(nil).Once()
+
+	svc := NewSubscriptionService(repo, nil, cfg)
+
+	sub, err := svc.Pause(context.Background(), pauseToken, 14)
+	require.NoError(t, err)
+	require.True(t, sub.IsPaused(time.Now()))
+	require.False(t, sub.IsPaused(time.Now().Add(15*24*time.Hour)), "Pause must expire on its own")
+
+	sub, err = svc.Resume(context.Background(), pauseToken)
+	require.NoError(t, err)
+	require.False(t, sub.IsPaused(time.Now()))
+
+	_, err = svc.Pause(context.Background(), hasher.Sign(token.PurposeUnsubscribe, "7"), 14)
+	require.ErrorIs(t, err, ErrNotFound, "Unsubscribe tokens must not grant pause rights")
+
+	repo.AssertExpectations(t)
+}
+
+func TestSetQuietHours(t *testing.T) {
+	cfg := &config.Config{TokenSecret: "secret"}
+	hasher := token.NewHasher(cfg.TokenSecret)
+	quiet := &model.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Kyiv", CatchUpSummary: true}
+
+	repo := new(repository.MockSubscriptionRepository)
+	repo.On("GetByID", mock.Anything, "1").Return(&model.Subscription{ID: "1", Email: "user@example.com", City: "Kyiv", Frequency: "hourly", Confirmed: true}, nil)
+	repo.On("GetByID", mock.Anything, "2").Return(&model.Subscription{ID: "2", Email: "user@example.com", City: "Lviv", Frequency: "daily", Confirmed: true}, nil)
+	repo.On("SetQuietHours", mock.Anything, "1", quiet).Return(nil).Once()
+
+	svc := NewSubscriptionService(repo, nil, cfg)
+
+	sub, err := svc.SetQuietHours(context.Background(), hasher.Sign(token.PurposePause, "1"), quiet)
+	require.NoError(t, err)
+	require.Equal(t, quiet, sub.QuietHours)
+
+	_, err = svc.SetQuietHours(context.Background(), hasher.Sign(token.PurposePause, "2"), quiet)
+	require.ErrorIs(t, err, ErrQuietHoursRequireHourly)
+
+	repo.AssertExpectations(t)
+}
+
+func TestSubscribeLimits(t *testing.T) {
+	cfg := &config.Config{
+		TokenSecret:              "secret",
+		ConfirmTokenTTL:          24 * time.Hour,
+		ConfirmResendCooldown:    5 * time.Minute,
+		MaxSubscriptionsPerEmail: 2,
+	}
+	req := &model.Subscription{Email: "user@example.com", City: "Kyiv", Frequency: "daily"}
+
+	tests := []struct {
+		name        string
+		existing    []*model.Subscription
+		relisted    []*model.Subscription
+		expectedErr error
+		retryAfter  bool
+	}{
+		{
+			name: "Confirmed subscription for the same city is rejected",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Kyiv", Confirmed: true},
+			},
+			expectedErr: ErrSubscriptionExists,
+		},
+		{
+			name: "Pending confirmation resent within cooldown is refused",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Kyiv", CreatedAt: time.Now().Add(-time.Minute)},
+			},
+			expectedErr: ErrResendCooldown,
+			retryAfter:  true,
+		},
+		{
+			name: "Email at its cap cannot add another city",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+				{Email: "user@example.com", City: "Odesa", CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectedErr: ErrSubscriptionLimit,
+			retryAfter:  true,
+		},
+		{
+			name: "Confirmed subscriptions at the cap are refused for good",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+				{Email: "user@example.com", City: "Odesa", Confirmed: true},
+			},
+			expectedErr: ErrSubscriptionLimit,
+		},
+		{
+			name: "Confirmed subscriptions at the cap are refused even with a pending one",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+				{Email: "user@example.com", City: "Odesa", Confirmed: true},
+				{Email: "user@example.com", City: "Dnipro", CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectedErr: ErrSubscriptionLimit,
+		},
+		{
+			name: "Concurrent request filling the cap with a pending subscription",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+			},
+			relisted: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+				{Email: "user@example.com", City: "Odesa", CreatedAt: time.Now()},
+			},
+			expectedErr: ErrSubscriptionLimit,
+			retryAfter:  true,
+		},
+		{
+			name: "Concurrent confirmation filling the cap",
+			existing: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+			},
+			relisted: []*model.Subscription{
+				{Email: "user@example.com", City: "Lviv", Confirmed: true},
+				{Email: "user@example.com", City: "Odesa", Confirmed: true},
+			},
+			expectedErr: ErrSubscriptionLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSubscriptionRepository)
+			repo.On("ListByEmail", mock.Anything, req.Email).Return(tt.existing, nil).Once()
+			if tt.relisted != nil {
+				// The cap check passes, but another request fills the cap before the insert
+				repo.On("Create", mock.Anything, mock.Anything, cfg.MaxSubscriptionsPerEmail, mock.AnythingOfType("time.Time")).
+					Return(repository.ErrLimitReached)
+				repo.On("ListByEmail", mock.Anything, req.Email).Return(tt.relisted, nil).Once()
+			}
+
+			svc := NewSubscriptionService(repo, nil, cfg)
+			err := svc.Subscribe(context.Background(), req)
+			require.ErrorIs(t, err, tt.expectedErr)
+
+			var retryErr *RetryAfterError
+			if tt.retryAfter {
+				require.ErrorAs(t, err, &retryErr)
+				require.Positive(t, retryErr.RetryAfter)
+			} else {
+				require.False(t, errors.As(err, &retryErr), "a refusal that waiting cannot lift must not ask to retry")
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForceConfirm(t *testing.T) {
+	cfg := &config.Config{ConfirmTokenTTL: 24 * time.Hour, TokenSecret: "secret"}
+
+	repo := new(repository.MockSubscriptionRepository)
+	sch := new(mockScheduler)
+
+	// A token far past its TTL must not stop support staff from confirming
+	sub := &model.Subscription{ID: "9", Email: "user@example.com", City: "Kyiv", Frequency: "daily", CreatedAt: time.Now().Add(-72 * time.Hour)}
+	repo.On("GetByID", mock.Anything, "9").Return(sub, nil)
+	repo.On("SetConfirmed", mock.Anything, "9").Return(nil)
+	repo.On("SetDigestByEmail", mock.Anything, "user@example.com", false).Return(int64(0), nil)
+	sch.On("StartFor", mock.Anything, sub).Once()
+
+	svc := NewSubscriptionService(repo, nil, cfg).WithScheduler(sch)
+	confirmed, err := svc.ForceConfirm(context.Background(), "9")
+	require.NoError(t, err)
+	require.True(t, confirmed.Confirmed)
+	require.NotNil(t, confirmed.ConfirmedAt)
+
+	repo.AssertExpectations(t)
+	sch.AssertExpectations(t)
+}
@@ -0,0 +1,9 @@
+package suppression_service
+
+import "errors"
+
+var (
+	ErrNotSuppressed    = errors.New("address is not suppressed")
+	ErrInvalidEvent     = errors.New("invalid email event")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
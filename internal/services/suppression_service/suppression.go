@@ -0,0 +1,198 @@
+package suppression_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/logger"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
+	"Weather-API-Application/internal/utils/validate"
+)
+
+// SignaturePrefix precedes the hex HMAC-SHA256 of the body in the webhook signature header.
+const SignaturePrefix = "sha256="
+
+// SuppressionService keeps addresses that bounce or complain from receiving further email.
+// Hard bounces and complaints suppress at once; soft bounces and permanent SMTP rejections
+// suppress after SUPPRESSION_FAILURE_THRESHOLD failures within SUPPRESSION_FAILURE_WINDOW of each other.
+type SuppressionService struct {
+	repo    repository.SuppressionRepository
+	cfg     *config.Config
+	webhook *token.Hasher
+}
+
+func NewSuppressionService(repo repository.SuppressionRepository, cfg *config.Config) *SuppressionService {
+	return &SuppressionService{
+		repo:    repo,
+		cfg:     cfg,
+		webhook: token.NewHasher(cfg.WebhookSecret),
+	}
+}
+
+// IsSuppressed reports whether email may not be sent to.
+func (s *SuppressionService) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	suppressed, err := s.repo.IsSuppressed(ctx, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	return suppressed, nil
+}
+
+// VerifySignature checks the webhook signature header against the raw request body.
+func (s *SuppressionService) VerifySignature(body []byte, signature string) error {
+	sig, ok := strings.CutPrefix(signature, SignaturePrefix)
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.webhook.Hash(string(body)))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ValidateEvents checks a whole webhook batch before any of it is applied.
+func ValidateEvents(events []model.EmailEvent) error {
+	for i, event := range events {
+		switch event.Type {
+		case model.EventHardBounce, model.EventSoftBounce, model.EventComplaint:
+		default:
+			return fmt.Errorf("%w: event %d has unknown type %q", ErrInvalidEvent, i, event.Type)
+		}
+		if !validate.IsValidEmail(event.Email) {
+			return fmt.Errorf("%w: event %d has an invalid email", ErrInvalidEvent, i)
+		}
+	}
+	return nil
+}
+
+// RecordEvents applies a validated webhook batch and returns how many events left their address suppressed.
+func (s *SuppressionService) RecordEvents(ctx context.Context, events []model.EmailEvent) (int, error) {
+	if err := ValidateEvents(events); err != nil {
+		return 0, err
+	}
+
+	suppressed := 0
+	for _, event := range events {
+		at := event.OccurredAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+
+		var (
+			blocked bool
+			err     error
+		)
+		switch event.Type {
+		case model.EventHardBounce:
+			err, blocked = s.suppress(ctx, event.Email, model.SuppressedHardBounce, at), true
+		case model.EventComplaint:
+			err, blocked = s.suppress(ctx, event.Email, model.SuppressedComplaint, at), true
+		case model.EventSoftBounce:
+			blocked, err = s.recordFailure(ctx, event.Email, at)
+		}
+		if err != nil {
+			return suppressed, err
+		}
+		if blocked {
+			suppressed++
+		}
+
+		logger.Info(ctx, "Email event recorded",
+			slog.String("email", event.Email),
+			slog.String("type", event.Type),
+			slog.String("detail", event.Detail))
+	}
+	return suppressed, nil
+}
+
+// RecordSendFailure counts a permanent rejection by the SMTP server towards the failure threshold.
+func (s *SuppressionService) RecordSendFailure(ctx context.Context, email string) error {
+	_, err := s.recordFailure(ctx, email, time.Now())
+	return err
+}
+
+// recordFailure counts a failure and suppresses the address once the threshold is reached.
+func (s *SuppressionService) recordFailure(ctx context.Context, email string, at time.Time) (bool, error) {
+	count, err := s.repo.RecordFailure(ctx, email, at, at.Add(-s.cfg.SuppressionFailureWindow))
+	if err != nil {
+		return false, fmt.Errorf("failed to record delivery failure: %w", err)
+	}
+	if count < s.cfg.SuppressionFailureThreshold {
+		return false, nil
+	}
+	return true, s.suppress(ctx, email, model.SuppressedRepeatedFailures, at)
+}
+
+func (s *SuppressionService) suppress(ctx context.Context, email, reason string, at time.Time) error {
+	if err := s.repo.Suppress(ctx, email, reason, at); err != nil {
+		return fmt.Errorf("failed to suppress address: %w", err)
+	}
+	logger.Info(ctx, "Address suppressed",
+		slog.String("email", email),
+		slog.String("reason", reason))
+	return nil
+}
+
+// List returns one page of suppressed addresses and their total number.
+func (s *SuppressionService) List(ctx context.Context, limit, offset int) ([]*model.Suppression, int, error) {
+	items, total, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppressions: %w", err)
+	}
+	return items, total, nil
+}
+
+// Lift allows sending to the address again and forgets its failure history.
+func (s *SuppressionService) Lift(ctx context.Context, email string) error {
+	if err := s.repo.Lift(ctx, email); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotSuppressed
+		}
+		return fmt.Errorf("failed to lift suppression: %w", err)
+	}
+	logger.Info(ctx, "Suppression lifted", slog.String("email", email))
+	return nil
+}
+
+// Guard wraps an email client so that nothing is sent to suppressed addresses and permanent
+// SMTP rejections count towards suppression. All services send through the guarded client.
+func (s *SuppressionService) Guard(inner client.Client) client.Client {
+	return &guardedClient{inner: inner, suppressions: s}
+}
+
+type guardedClient struct {
+	inner        client.Client
+	suppressions *SuppressionService
+}
+
+func (g *guardedClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	// Fail closed: sending to an address that complained is worse than delaying an email
+	suppressed, err := g.suppressions.IsSuppressed(ctx, to)
+	if err != nil {
+		return err
+	}
+	if suppressed {
+		return fmt.Errorf("%w: %s", client.ErrSuppressed, to)
+	}
+
+	err = g.inner.SendEmail(ctx, to, subject, body, headers...)
+	if isPermanentRejection(err) {
+		if recordErr := g.suppressions.RecordSendFailure(ctx, to); recordErr != nil {
+			logger.Error(ctx, recordErr, slog.String("email", to))
+		}
+	}
+	return err
+}
+
+// isPermanentRejection reports whether the SMTP server refused the message with a 5xx reply.
+func isPermanentRejection(err error) bool {
+	var smtpErr *textproto.Error
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600
+}
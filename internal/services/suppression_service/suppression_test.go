@@ -0,0 +1,190 @@
+package suppression_service
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/client"
+	"Weather-API-Application/internal/config"
+	"Weather-API-Application/internal/model"
+	"Weather-API-Application/internal/repository"
+	"Weather-API-Application/internal/utils/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailClient struct {
+	sent []string
+	err  error
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, to, subject string, body client.Body, headers ...client.Header) error {
+	c.sent = append(c.sent, to)
+	return c.err
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		WebhookSecret:               "hook-secret",
+		SuppressionFailureThreshold: 3,
+		SuppressionFailureWindow:    7 * 24 * time.Hour,
+	}
+}
+
+func TestRecordEvents(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	window := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name           string
+		event          model.EmailEvent
+		mockSetup      func(*repository.MockSuppressionRepository)
+		wantSuppressed int
+	}{
+		{
+			name:  "Hard bounce suppresses at once",
+			event: model.EmailEvent{Type: model.EventHardBounce, Email: "gone@example.com", OccurredAt: at},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("Suppress", mock.Anything, "gone@example.com", model.SuppressedHardBounce, at).Return(nil)
+			},
+			wantSuppressed: 1,
+		},
+		{
+			name:  "Complaint suppresses at once",
+			event: model.EmailEvent{Type: model.EventComplaint, Email: "angry@example.com", OccurredAt: at},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("Suppress", mock.Anything, "angry@example.com", model.SuppressedComplaint, at).Return(nil)
+			},
+			wantSuppressed: 1,
+		},
+		{
+			name:  "Soft bounce below the threshold is only counted",
+			event: model.EmailEvent{Type: model.EventSoftBounce, Email: "full@example.com", OccurredAt: at},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("RecordFailure", mock.Anything, "full@example.com", at, at.Add(-window)).Return(2, nil)
+			},
+			wantSuppressed: 0,
+		},
+		{
+			name:  "Soft bounce reaching the threshold suppresses",
+			event: model.EmailEvent{Type: model.EventSoftBounce, Email: "full@example.com", OccurredAt: at},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("RecordFailure", mock.Anything, "full@example.com", at, at.Add(-window)).Return(3, nil)
+				m.On("Suppress", mock.Anything, "full@example.com", model.SuppressedRepeatedFailures, at).Return(nil)
+			},
+			wantSuppressed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSuppressionRepository)
+			tt.mockSetup(repo)
+
+			suppressed, err := NewSuppressionService(repo, newTestConfig()).RecordEvents(context.Background(), []model.EmailEvent{tt.event})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSuppressed, suppressed)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordEventsRejectsInvalidBatch(t *testing.T) {
+	repo := new(repository.MockSuppressionRepository)
+	events := []model.EmailEvent{
+		{Type: model.EventHardBounce, Email: "gone@example.com"},
+		{Type: "delivered", Email: "fine@example.com"},
+	}
+
+	_, err := NewSuppressionService(repo, newTestConfig()).RecordEvents(context.Background(), events)
+	require.ErrorIs(t, err, ErrInvalidEvent)
+	// Nothing from the batch is applied, not even the valid first event
+	repo.AssertNotCalled(t, "Suppress", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVerifySignature(t *testing.T) {
+	cfg := newTestConfig()
+	svc := NewSuppressionService(new(repository.MockSuppressionRepository), cfg)
+	body := []byte(`{"events":[]}`)
+	valid := SignaturePrefix + token.NewHasher(cfg.WebhookSecret).Hash(string(body))
+
+	require.NoError(t, svc.VerifySignature(body, valid))
+	require.ErrorIs(t, svc.VerifySignature(body, ""), ErrInvalidSignature)
+	require.ErrorIs(t, svc.VerifySignature(body, valid[len(SignaturePrefix):]), ErrInvalidSignature)
+	require.ErrorIs(t, svc.VerifySignature([]byte(`{"events":null}`), valid), ErrInvalidSignature)
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		sendErr   error
+		mockSetup func(*repository.MockSuppressionRepository)
+		wantErr   error
+		wantSent  bool
+	}{
+		{
+			name: "Sends to an address that is not suppressed",
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("IsSuppressed", mock.Anything, "user@example.com").Return(false, nil)
+			},
+			wantSent: true,
+		},
+		{
+			name: "Refuses a suppressed address",
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("IsSuppressed", mock.Anything, "user@example.com").Return(true, nil)
+			},
+			wantErr: client.ErrSuppressed,
+		},
+		{
+			name: "Refuses when the suppression list cannot be read",
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("IsSuppressed", mock.Anything, "user@example.com").Return(false, errors.New("db down"))
+			},
+			wantErr: errors.New("failed to check suppression list: db down"),
+		},
+		{
+			name:    "Permanent rejection counts as a failure",
+			sendErr: &textproto.Error{Code: 550, Msg: "mailbox unavailable"},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("IsSuppressed", mock.Anything, "user@example.com").Return(false, nil)
+				m.On("RecordFailure", mock.Anything, "user@example.com", mock.Anything, mock.Anything).Return(1, nil)
+			},
+			wantErr:  &textproto.Error{Code: 550, Msg: "mailbox unavailable"},
+			wantSent: true,
+		},
+		{
+			name:    "Temporary rejection is not counted",
+			sendErr: &textproto.Error{Code: 451, Msg: "try again later"},
+			mockSetup: func(m *repository.MockSuppressionRepository) {
+				m.On("IsSuppressed", mock.Anything, "user@example.com").Return(false, nil)
+			},
+			wantErr:  &textproto.Error{Code: 451, Msg: "try again later"},
+			wantSent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(repository.MockSuppressionRepository)
+			tt.mockSetup(repo)
+			inner := &fakeEmailClient{err: tt.sendErr}
+
+			err := NewSuppressionService(repo, newTestConfig()).Guard(inner).SendEmail(context.Background(), "user@example.com", "subject", client.Body{Text: "body"})
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else if errors.Is(tt.wantErr, client.ErrSuppressed) {
+				require.ErrorIs(t, err, client.ErrSuppressed)
+			} else {
+				require.EqualError(t, err, tt.wantErr.Error())
+			}
+			assert.Equal(t, tt.wantSent, len(inner.sent) == 1)
+			repo.AssertExpectations(t)
+		})
+	}
+}
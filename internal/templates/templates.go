@@ -0,0 +1,148 @@
+// Package templates renders the emails the service sends. Every email has an HTML and a plain-text template,
+// both with the shared "weather" and "links" blocks of the partials templates in scope. The defaults are
+// embedded in the binary; any of them can be replaced by a file of the same name in a directory.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Emails with templates.
+const (
+	Confirm       = "confirm"
+	Update        = "update"
+	CatchUp       = "catch_up"
+	Digest        = "digest"
+	PrivacyExport = "privacy_export"
+	PrivacyErase  = "privacy_erase"
+)
+
+// Names lists every email with templates.
+var Names = []string{Confirm, Update, CatchUp, Digest, PrivacyExport, PrivacyErase}
+
+const (
+	partials = "partials"
+	htmlExt  = ".html.tmpl"
+	textExt  = ".txt.tmpl"
+)
+
+//go:embed defaults/*.tmpl
+var defaults embed.FS
+
+// Set holds the parsed templates of every email.
+type Set struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// Default returns the embedded templates.
+func Default() *Set {
+	set, err := Load("")
+	if err != nil {
+		panic(fmt.Sprintf("embedded email templates are invalid: %v", err))
+	}
+	return set
+}
+
+// Load returns the embedded templates with those found in dir in their place. Files in dir are named like
+// the defaults, e.g. update.html.tmpl or partials.txt.tmpl; any other file is an error, so that a misspelt
+// name does not go unnoticed. An empty dir loads the defaults only.
+func Load(dir string) (*Set, error) {
+	sources, err := readDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := readOverrides(dir, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	set := &Set{
+		html: make(map[string]*htmltemplate.Template, len(Names)),
+		text: make(map[string]*texttemplate.Template, len(Names)),
+	}
+	for _, name := range Names {
+		html, err := htmltemplate.New(name).Option("missingkey=error").Parse(sources[partials+htmlExt])
+		if err == nil {
+			html, err = html.Parse(sources[name+htmlExt])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name+htmlExt, err)
+		}
+		text, err := texttemplate.New(name).Option("missingkey=error").Parse(sources[partials+textExt])
+		if err == nil {
+			text, err = text.Parse(sources[name+textExt])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name+textExt, err)
+		}
+		set.html[name] = html
+		set.text[name] = text
+	}
+	return set, nil
+}
+
+func readDefaults() (map[string]string, error) {
+	sources := make(map[string]string)
+	entries, err := defaults.ReadDir("defaults")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		content, err := defaults.ReadFile("defaults/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		sources[entry.Name()] = string(content)
+	}
+	return sources, nil
+}
+
+func readOverrides(dir string, sources map[string]string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read email template directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, ok := sources[entry.Name()]; !ok {
+			return fmt.Errorf("unknown email template %s in %s", entry.Name(), dir)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read email template: %w", err)
+		}
+		sources[entry.Name()] = string(content)
+	}
+	return nil
+}
+
+// Render executes both templates of an email. Values are escaped for HTML in the HTML part only.
+func (s *Set) Render(name string, data any) (html, text string, err error) {
+	htmlTmpl, ok := s.html[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s: %w", name+htmlExt, err)
+	}
+	html = buf.String()
+
+	buf.Reset()
+	if err := s.text[name].Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s: %w", name+textExt, err)
+	}
+	return html, buf.String(), nil
+}
@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type link struct {
+	URL string
+}
+
+func TestRenderEscapesHTMLOnly(t *testing.T) {
+	html, text, err := Default().Render(Confirm, link{URL: `https://example.com/confirm?a=1&b="2"`})
+	require.NoError(t, err)
+
+	assert.Contains(t, html, `href="https://example.com/confirm?a=1&amp;b=%222%22"`)
+	assert.Contains(t, text, `https://example.com/confirm?a=1&b="2"`)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+		check   func(t *testing.T, set *Set)
+	}{
+		{
+			name:  "Override replaces only its own part",
+			files: map[string]string{"confirm.txt.tmpl": "Confirm: {{.URL}}"},
+			check: func(t *testing.T, set *Set) {
+				html, text, err := set.Render(Confirm, link{URL: "https://example.com/c"})
+				require.NoError(t, err)
+				assert.Equal(t, "Confirm: https://example.com/c", text)
+				assert.Contains(t, html, `<a href="https://example.com/c">here</a>`)
+			},
+		},
+		{
+			name: "Overridden partials apply to every email",
+			files: map[string]string{
+				"partials.html.tmpl": `{{define "weather"}}{{.City}}{{end}}{{define "links"}}{{.Unsubscribe}}{{end}}`,
+				"partials.txt.tmpl":  `{{define "weather"}}{{.City}}{{end}}{{define "links"}}{{.Unsubscribe}}{{end}}`,
+			},
+			check: func(t *testing.T, set *Set) {
+				html, _, err := set.Render(Update, struct {
+					City  string
+					Links struct{ Unsubscribe string }
+				}{City: "Kyiv", Links: struct{ Unsubscribe string }{"u"}})
+				require.NoError(t, err)
+				assert.Equal(t, "Kyiv<br><br>u\n", html)
+			},
+		},
+		{
+			name:    "Misspelt template name",
+			files:   map[string]string{"confrim.html.tmpl": "x"},
+			wantErr: "unknown email template confrim.html.tmpl",
+		},
+		{
+			name:    "Invalid template",
+			files:   map[string]string{"update.html.tmpl": "{{.City"},
+			wantErr: "failed to parse email template update.html.tmpl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			}
+
+			set, err := Load(dir)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, set)
+		})
+	}
+}
+
+func TestLoadMissingDirectory(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
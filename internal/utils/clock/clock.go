@@ -0,0 +1,172 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and paces periodic work. Real is the system clock; tests use Fake to move
+// time forward without waiting.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	// After delivers the time once d has passed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Ticker delivers ticks like time.Ticker: a tick that is not received before the next one is dropped.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+// Real returns the system clock.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// Fake is a clock that only moves when told to. Its tickers and timers fire from Advance.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	timers  []fakeTimer
+}
+
+type fakeTimer struct {
+	c  chan time.Time
+	at time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTicker{clock: f, c: make(chan time.Time, 1), interval: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.timers = append(f.timers, fakeTimer{c: c, at: f.now.Add(d)})
+	return c
+}
+
+// Set moves the clock to t, which must not be before the current time, firing every ticker and timer that falls due.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t.Before(f.now) {
+		panic("clock: fake clock moved backwards")
+	}
+	f.now = t
+	for _, tk := range f.tickers {
+		if tk.next.After(t) {
+			continue
+		}
+		// One tick at most, as a receiver that fell behind would get from time.Ticker
+		select {
+		case tk.c <- tk.next:
+		default:
+		}
+		for !tk.next.After(t) {
+			tk.next = tk.next.Add(tk.interval)
+		}
+	}
+	pending := f.timers[:0]
+	for _, tm := range f.timers {
+		if tm.at.After(t) {
+			pending = append(pending, tm)
+			continue
+		}
+		tm.c <- tm.at
+	}
+	f.timers = pending
+}
+
+// Advance moves the clock forward by d, firing every ticker and timer that falls due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Tickers returns how many tickers are running, so a test can wait for the code under test to start one.
+func (f *Fake) Tickers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tickers)
+}
+
+// Timers returns how many channels returned by After have yet to fire, so a test can wait for the code
+// under test to start waiting.
+func (f *Fake) Timers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+type fakeTicker struct {
+	clock    *Fake
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, tk := range t.clock.tickers {
+		if tk == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeTicker(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Minute)
+
+	c.Advance(59 * time.Second)
+	assert.Empty(t, ticker.C(), "No tick before the interval has passed")
+
+	c.Advance(time.Second)
+	require.Len(t, ticker.C(), 1)
+	assert.Equal(t, start.Add(time.Minute), <-ticker.C())
+
+	c.Advance(3 * time.Minute)
+	require.Len(t, ticker.C(), 1, "Ticks a receiver fell behind on are dropped")
+	assert.Equal(t, start.Add(2*time.Minute), <-ticker.C())
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(5*time.Minute), <-ticker.C(), "The ticker keeps its phase after dropping ticks")
+
+	ticker.Stop()
+	c.Advance(time.Hour)
+	assert.Empty(t, ticker.C(), "A stopped ticker does not fire")
+	assert.Zero(t, c.Tickers())
+}
+
+func TestFakeAfter(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	assert.Equal(t, start, <-c.After(0), "A non-positive wait fires right away")
+
+	first := c.After(time.Minute)
+	second := c.After(2 * time.Minute)
+	require.Equal(t, 2, c.Timers())
+
+	c.Advance(time.Minute)
+	require.Len(t, first, 1)
+	assert.Equal(t, start.Add(time.Minute), <-first)
+	assert.Empty(t, second, "A timer does not fire before its time")
+	assert.Equal(t, 1, c.Timers())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, start.Add(2*time.Minute), <-second, "A timer fires with the time it was due at")
+	assert.Zero(t, c.Timers())
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+)
+
+// Summary tracks the count, sum, minimum and maximum of observed values and is published as an
+// expvar, so it appears on /debug/vars next to the runtime's own variables.
+type Summary struct {
+	mu    sync.Mutex
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// NewSummary creates a summary published under name. Like expvar.Publish it panics if the name is taken,
+// so summaries are created once, at package level.
+func NewSummary(name string) *Summary {
+	s := &Summary{}
+	expvar.Publish(name, s)
+	return s
+}
+
+// NewCounter creates an integer counter published under name.
+func NewCounter(name string) *expvar.Int {
+	return expvar.NewInt(name)
+}
+
+// Observe records one value.
+func (s *Summary) Observe(v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if s.count == 0 || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+}
+
+// String renders the summary as JSON, as expvar.Var requires.
+func (s *Summary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var mean float64
+	if s.count > 0 {
+		mean = s.sum / float64(s.count)
+	}
+	out, _ := json.Marshal(struct {
+		Count int64   `json:"count"`
+		Sum   float64 `json:"sum"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+		Mean  float64 `json:"mean"`
+	}{s.count, s.sum, s.min, s.max, mean})
+	return string(out)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	s := NewSummary("test_summary")
+	require.JSONEq(t, `{"count":0,"sum":0,"min":0,"max":0,"mean":0}`, s.String())
+
+	for _, v := range []float64{4, 1, 7} {
+		s.Observe(v)
+	}
+
+	var got map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("test_summary").String()), &got))
+	require.Equal(t, map[string]float64{"count": 3, "sum": 12, "min": 1, "max": 7, "mean": 4}, got)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a fixed-window rate limiter keyed by an arbitrary string such as a client IP.
+type Limiter struct {
+	limit     int
+	window    time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	windows   map[string]*counter
+	lastSweep time.Time
+}
+
+type counter struct {
+	start time.Time
+	hits  int
+}
+
+// New creates a limiter allowing limit hits per key within each window. A non-positive limit disables limiting.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		windows: make(map[string]*counter),
+	}
+}
+
+// Allow records a hit for key. If the key is over its limit it returns false and the time until the window resets.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	c, ok := l.windows[key]
+	if !ok || now.Sub(c.start) >= l.window {
+		c = &counter{start: now}
+		l.windows[key] = c
+	}
+	if c.hits >= l.limit {
+		return false, c.start.Add(l.window).Sub(now)
+	}
+	c.hits++
+	return true, 0
+}
+
+// sweep drops expired windows at most once per window so idle keys do not accumulate.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for key, c := range l.windows {
+		if now.Sub(c.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+	l.lastSweep = now
+}
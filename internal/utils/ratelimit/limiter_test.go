@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("1.1.1.1")
+	require.True(t, ok)
+	ok, _ = l.Allow("1.1.1.1")
+	require.True(t, ok)
+
+	now = now.Add(20 * time.Second)
+	ok, retryAfter := l.Allow("1.1.1.1")
+	require.False(t, ok, "Third hit within the window must be refused")
+	require.Equal(t, 40*time.Second, retryAfter, "Retry-After must point at the end of the window")
+
+	ok, _ = l.Allow("2.2.2.2")
+	require.True(t, ok, "Keys are limited independently")
+
+	now = now.Add(40 * time.Second)
+	ok, _ = l.Allow("1.1.1.1")
+	require.True(t, ok, "A new window starts once the old one has elapsed")
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("1.1.1.1")
+		require.True(t, ok)
+	}
+}
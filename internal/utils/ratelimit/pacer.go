@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"Weather-API-Application/internal/utils/clock"
+)
+
+// Pacer spaces events evenly so that no more than a given number happen per second, making callers
+// wait for their turn. Unlike Limiter it never refuses an event, it only delays it.
+type Pacer struct {
+	interval time.Duration
+	clock    clock.Clock
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// NewPacer creates a pacer allowing perSecond events per second. A non-positive rate disables pacing.
+func NewPacer(perSecond float64) *Pacer {
+	p := &Pacer{clock: clock.Real()}
+	if perSecond > 0 {
+		p.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return p
+}
+
+// WithClock replaces the clock that turns are booked and waited on. It must be set before the pacer is used.
+func (p *Pacer) WithClock(c clock.Clock) *Pacer {
+	p.clock = c
+	return p
+}
+
+// Wait blocks until the caller's turn or until ctx is done, in which case it returns ctx.Err().
+func (p *Pacer) Wait(ctx context.Context) error {
+	wait := p.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-p.clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve books the next free turn and returns how long until it comes. An idle pacer does not save up
+// turns, so a burst after a quiet period is paced like any other.
+func (p *Pacer) reserve() time.Duration {
+	if p.interval <= 0 {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	return at.Sub(now)
+}
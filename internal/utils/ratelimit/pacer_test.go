@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Weather-API-Application/internal/utils/clock"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacerReserve(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := NewPacer(4).WithClock(c)
+
+	require.Zero(t, p.reserve(), "The first event goes right away")
+	require.Equal(t, 250*time.Millisecond, p.reserve())
+	require.Equal(t, 500*time.Millisecond, p.reserve(), "Waiting callers queue up behind each other")
+
+	c.Advance(time.Minute)
+	require.Zero(t, p.reserve(), "Turns are not saved up while idle")
+	require.Equal(t, 250*time.Millisecond, p.reserve())
+}
+
+func TestPacerDisabled(t *testing.T) {
+	p := NewPacer(0)
+	for range 100 {
+		require.NoError(t, p.Wait(context.Background()))
+	}
+}
+
+func TestPacerWaitCancelled(t *testing.T) {
+	p := NewPacer(0.001)
+	require.NoError(t, p.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, p.Wait(ctx), context.Canceled)
+}
+
+func TestPacerWaitFollowsClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := NewPacer(1).WithClock(c)
+	require.NoError(t, p.Wait(context.Background()))
+
+	done := make(chan error, 1)
+	go func() { done <- p.Wait(context.Background()) }()
+	require.Eventually(t, func() bool { return c.Timers() == 1 }, time.Second, time.Millisecond)
+
+	c.Advance(999 * time.Millisecond)
+	require.Empty(t, done, "The turn must not come before the clock reaches it")
+	c.Advance(time.Millisecond)
+	require.NoError(t, <-done)
+}
@@ -1,6 +1,11 @@
 package response
 
 import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,3 +23,11 @@ func WriteErrorJSON(ctx *gin.Context, statusCode int, err error, userMsg string)
 		Error: userMsg,
 	})
 }
+
+// WriteTooManyRequests writes a 429 error with a Retry-After header (in whole seconds) when retryAfter is known.
+func WriteTooManyRequests(ctx *gin.Context, err error, retryAfter time.Duration, userMsg string) {
+	if retryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	WriteErrorJSON(ctx, http.StatusTooManyRequests, err, userMsg)
+}
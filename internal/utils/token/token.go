@@ -0,0 +1,100 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purposes scope signed tokens so a link for one action cannot be replayed for another.
+// PurposePause also covers the other subscription settings links (resume, quiet hours).
+const (
+	PurposeUnsubscribe    = "unsubscribe"
+	PurposeUnsubscribeAll = "unsubscribe-all"
+	PurposePause          = "pause"
+	PurposePrivacyExport  = "privacy-export"
+	PurposePrivacyErase   = "privacy-erase"
+)
+
+var (
+	ErrInvalid = errors.New("invalid token")
+	ErrExpired = errors.New("token expired")
+)
+
+// Hasher derives the keyed hash under which tokens are stored and looked up.
+type Hasher struct {
+	secret []byte
+}
+
+func NewHasher(secret string) *Hasher {
+	return &Hasher{secret: []byte(secret)}
+}
+
+// Hash returns the hex encoded HMAC-SHA256 of the raw token.
+func (h *Hasher) Hash(raw string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign binds value to purpose with the server secret, producing a token that can be verified without storage.
+func (h *Hasher) Sign(purpose, value string) string {
+	return value + "." + h.Hash(purpose+":"+value)
+}
+
+// Verify checks a token produced by Sign for the same purpose and returns the signed value.
+func (h *Hasher) Verify(purpose, signed string) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i <= 0 {
+		return "", false
+	}
+	value, sig := signed[:i], signed[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(h.Hash(purpose+":"+value))) {
+		return "", false
+	}
+	return value, true
+}
+
+// SignExpiring is like Sign but the token stops verifying after expiresAt.
+// The value is encoded so it may safely contain any characters, e.g. an email address.
+func (h *Hasher) SignExpiring(purpose, value string, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value)) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return h.Sign(purpose, payload)
+}
+
+// VerifyExpiring checks a token produced by SignExpiring and returns the signed value.
+// It returns ErrExpired for a genuine token past its expiry and ErrInvalid for anything else.
+func (h *Hasher) VerifyExpiring(purpose, signed string, now time.Time) (string, error) {
+	payload, ok := h.Verify(purpose, signed)
+	if !ok {
+		return "", ErrInvalid
+	}
+	i := strings.LastIndex(payload, ".")
+	if i <= 0 {
+		return "", ErrInvalid
+	}
+	value, err := base64.RawURLEncoding.DecodeString(payload[:i])
+	if err != nil {
+		return "", ErrInvalid
+	}
+	expiresAt, err := strconv.ParseInt(payload[i+1:], 10, 64)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return "", ErrExpired
+	}
+	return string(value), nil
+}
+
+// New generates a new random raw token.
+func New() string {
+	return uuid.New().String()
+}
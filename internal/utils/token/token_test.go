@@ -0,0 +1,62 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	h := NewHasher("secret")
+
+	require.Equal(t, h.Hash("abc"), h.Hash("abc"), "Hash must be deterministic so lookups by hash work")
+	require.NotEqual(t, h.Hash("abc"), h.Hash("abd"), "Different tokens must produce different hashes")
+	require.NotEqual(t, h.Hash("abc"), NewHasher("other").Hash("abc"), "Hash must depend on the server secret")
+	require.NotContains(t, h.Hash("abc"), "abc", "Raw token must not leak into the stored value")
+	require.Len(t, h.Hash("abc"), 64)
+}
+
+func TestNew(t *testing.T) {
+	require.NotEqual(t, New(), New())
+}
+
+func TestSignVerify(t *testing.T) {
+	h := NewHasher("secret")
+	signed := h.Sign(PurposeUnsubscribe, "42")
+
+	value, ok := h.Verify(PurposeUnsubscribe, signed)
+	require.True(t, ok)
+	require.Equal(t, "42", value)
+
+	_, ok = h.Verify("confirm", signed)
+	require.False(t, ok, "Signature must be bound to its purpose")
+
+	_, ok = NewHasher("other").Verify(PurposeUnsubscribe, signed)
+	require.False(t, ok, "Signature must depend on the server secret")
+
+	_, ok = h.Verify(PurposeUnsubscribe, "43"+signed[2:])
+	require.False(t, ok, "Tampered value must be rejected")
+
+	_, ok = h.Verify(PurposeUnsubscribe, New())
+	require.False(t, ok, "Random tokens are not signed tokens")
+}
+
+func TestSignVerifyExpiring(t *testing.T) {
+	h := NewHasher("secret")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	signed := h.SignExpiring(PurposePrivacyExport, "first.last+tag@example.com", now.Add(time.Hour))
+
+	value, err := h.VerifyExpiring(PurposePrivacyExport, signed, now)
+	require.NoError(t, err)
+	require.Equal(t, "first.last+tag@example.com", value)
+
+	_, err = h.VerifyExpiring(PurposePrivacyExport, signed, now.Add(time.Hour))
+	require.ErrorIs(t, err, ErrExpired)
+
+	_, err = h.VerifyExpiring(PurposePrivacyErase, signed, now)
+	require.ErrorIs(t, err, ErrInvalid, "Signature must be bound to its purpose")
+
+	_, err = h.VerifyExpiring(PurposePrivacyExport, h.Sign(PurposePrivacyExport, "user@example.com"), now)
+	require.ErrorIs(t, err, ErrInvalid, "Tokens without an expiry must be rejected")
+}
@@ -3,6 +3,7 @@ package validate
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
 func IsValidEmail(email string) bool {
@@ -18,3 +19,27 @@ func IsValidFrequency(frequency string) bool {
 	freq := strings.ToLower(strings.TrimSpace(frequency))
 	return freq == "hourly" || freq == "daily"
 }
+
+func IsValidPauseDays(days, maxDays int) bool {
+	return days >= 1 && days <= maxDays
+}
+
+// IsValidQuietHours checks a quiet window given as "15:04" start/end times in an IANA timezone.
+func IsValidQuietHours(start, end, timezone string) bool {
+	startAt, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endAt, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+	if startAt.Equal(endAt) {
+		return false
+	}
+	if strings.TrimSpace(timezone) == "" {
+		return false
+	}
+	_, err = time.LoadLocation(timezone)
+	return err == nil
+}
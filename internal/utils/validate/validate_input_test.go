@@ -215,3 +215,129 @@ func TestIsValidFrequency(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidPauseDays(t *testing.T) {
+	tests := []struct {
+		name     string
+		days     int
+		expected bool
+		reason   string
+	}{
+		{
+			name:     "Zero days should be invalid",
+			days:     0,
+			expected: false,
+			reason:   "A pause must last at least one day",
+		},
+		{
+			name:     "Negative days should be invalid",
+			days:     -3,
+			expected: false,
+			reason:   "Negative durations make no sense",
+		},
+		{
+			name:     "One day should be valid",
+			days:     1,
+			expected: true,
+			reason:   "Lower bound is inclusive",
+		},
+		{
+			name:     "Maximum days should be valid",
+			days:     30,
+			expected: true,
+			reason:   "Upper bound is inclusive",
+		},
+		{
+			name:     "More than maximum should be invalid",
+			days:     31,
+			expected: false,
+			reason:   "Pauses longer than the configured maximum are rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsValidPauseDays(tt.days, 30)
+			require.Equal(t, tt.expected, result,
+				"Pause days validation failed for %d. Expected: %v, Got: %v. Reason: %s",
+				tt.days, tt.expected, result, tt.reason)
+		})
+	}
+}
+
+func TestIsValidQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		timezone string
+		expected bool
+		reason   string
+	}{
+		{
+			name:     "Window spanning midnight should be valid",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "Europe/Kyiv",
+			expected: true,
+			reason:   "Quiet hours usually cover the night",
+		},
+		{
+			name:     "Same-day window should be valid",
+			start:    "13:00",
+			end:      "14:30",
+			timezone: "UTC",
+			expected: true,
+			reason:   "Windows within a single day are allowed",
+		},
+		{
+			name:     "Equal start and end should be invalid",
+			start:    "08:00",
+			end:      "08:00",
+			timezone: "UTC",
+			expected: false,
+			reason:   "An empty (or full-day) window is ambiguous",
+		},
+		{
+			name:     "Hour out of range should be invalid",
+			start:    "25:00",
+			end:      "07:00",
+			timezone: "UTC",
+			expected: false,
+			reason:   "Times must use the 24-hour HH:MM format",
+		},
+		{
+			name:     "Missing minutes should be invalid",
+			start:    "22",
+			end:      "07:00",
+			timezone: "UTC",
+			expected: false,
+			reason:   "Times must use the 24-hour HH:MM format",
+		},
+		{
+			name:     "Empty timezone should be invalid",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "",
+			expected: false,
+			reason:   "Timezone is required to evaluate the window",
+		},
+		{
+			name:     "Unknown timezone should be invalid",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "Mars/Base",
+			expected: false,
+			reason:   "Only IANA timezone names are accepted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsValidQuietHours(tt.start, tt.end, tt.timezone)
+			require.Equal(t, tt.expected, result,
+				"Quiet hours validation failed for '%s'-'%s' %s. Expected: %v, Got: %v. Reason: %s",
+				tt.start, tt.end, tt.timezone, tt.expected, result, tt.reason)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE weather_subscriptions
+    RENAME COLUMN token TO confirm_token;
+ALTER TABLE weather_subscriptions
+    ALTER COLUMN confirm_token DROP NOT NULL;
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS unsubscribe_token TEXT UNIQUE NULL;
+
+-- Confirmed rows keep their old token for unsubscribing; pending rows keep it for confirming.
+UPDATE weather_subscriptions
+SET unsubscribe_token = confirm_token,
+    confirm_token     = NULL
+WHERE confirmed = TRUE;
+
+-- +goose Down
+UPDATE weather_subscriptions
+SET confirm_token = unsubscribe_token
+WHERE confirm_token IS NULL;
+DELETE FROM weather_subscriptions
+WHERE confirm_token IS NULL;
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS unsubscribe_token;
+ALTER TABLE weather_subscriptions
+    ALTER COLUMN confirm_token SET NOT NULL;
+ALTER TABLE weather_subscriptions
+    RENAME COLUMN confirm_token TO token;
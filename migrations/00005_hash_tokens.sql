@@ -0,0 +1,12 @@
+-- +goose Up
+-- Existing rows hold raw tokens and are rehashed by the application on startup;
+-- rows inserted from now on are written with hashed tokens.
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS token_hashed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE weather_subscriptions
+    ALTER COLUMN token_hashed SET DEFAULT TRUE;
+
+-- +goose Down
+-- Hashed tokens cannot be turned back into raw ones; links issued after the upgrade stop working.
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS token_hashed;
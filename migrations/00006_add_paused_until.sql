@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS paused_until TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS paused_until;
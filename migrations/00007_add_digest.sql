@@ -0,0 +1,10 @@
+-- +goose Up
+-- Digest mode is a per-subscriber preference; it is kept identical across all confirmed rows of an email.
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS digest BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_email ON weather_subscriptions (email);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_email;
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS digest;
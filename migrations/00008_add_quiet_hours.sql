@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS quiet_start TEXT NULL,
+    ADD COLUMN IF NOT EXISTS quiet_end TEXT NULL,
+    ADD COLUMN IF NOT EXISTS quiet_timezone TEXT NULL,
+    ADD COLUMN IF NOT EXISTS quiet_catch_up BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS quiet_start,
+    DROP COLUMN IF EXISTS quiet_end,
+    DROP COLUMN IF EXISTS quiet_timezone,
+    DROP COLUMN IF EXISTS quiet_catch_up;
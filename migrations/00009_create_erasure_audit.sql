@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS erasure_audit (
+    id SERIAL PRIMARY KEY,
+    email_hash TEXT NOT NULL,
+    subscriptions_removed INTEGER NOT NULL,
+    erased_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_audit_email_hash ON erasure_audit (email_hash);
+
+-- +goose Down
+DROP TABLE IF EXISTS erasure_audit;
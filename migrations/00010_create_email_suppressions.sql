@@ -0,0 +1,12 @@
+-- +goose Up
+-- One row per address with delivery problems; it only blocks sending once suppressed_at is set.
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email TEXT PRIMARY KEY,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    last_event_at TIMESTAMP NOT NULL,
+    suppressed_at TIMESTAMP NULL,
+    reason TEXT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_suppressions;
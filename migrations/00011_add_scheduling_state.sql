@@ -0,0 +1,17 @@
+-- +goose Up
+-- next_run_at is the slot a confirmed subscription is due next; NULL means the dispatcher has not scheduled it yet.
+-- quiet_backlog holds the slots skipped during quiet hours until the catch-up summary is sent.
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS quiet_backlog JSONB NULL;
+
+CREATE INDEX IF NOT EXISTS idx_weather_subscriptions_next_run_at
+    ON weather_subscriptions (next_run_at)
+    WHERE confirmed = TRUE;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_weather_subscriptions_next_run_at;
+
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS next_run_at,
+    DROP COLUMN IF EXISTS quiet_backlog;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- A dispatcher leases the subscriptions it claims until lease_expires_at; expired leases are taken over by other instances.
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS claimed_by TEXT NULL,
+    ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS claimed_by,
+    DROP COLUMN IF EXISTS lease_expires_at;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- One row per subscription and slot; a digest writes a row for each of its cities. Rows go with their
+-- subscription, so unsubscribing and erasure remove the delivery history too.
+CREATE TABLE IF NOT EXISTS deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES weather_subscriptions(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    city TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    scheduled_for TIMESTAMP NOT NULL,
+    status TEXT CHECK (status IN ('queued', 'sent', 'failed', 'suppressed')) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    provider_response TEXT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NULL,
+    UNIQUE (subscription_id, kind, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_created ON deliveries (LOWER(email), created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_deliveries_status_created ON deliveries (status, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS deliveries;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Transient failures are retried with backoff ('retrying' until next_attempt_at); deliveries that fail
+-- permanently or run out of attempts stay 'failed' and form the dead-letter queue.
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'retrying', 'failed', 'suppressed')),
+    ADD COLUMN IF NOT EXISTS failure TEXT NULL,
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_next_attempt ON deliveries (next_attempt_at) WHERE status = 'retrying';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_deliveries_next_attempt;
+UPDATE deliveries SET status = 'failed' WHERE status = 'retrying';
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'failed', 'suppressed')),
+    DROP COLUMN IF EXISTS failure,
+    DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A single row shared by every scheduler instance; while paused, no instance claims due slots or retries.
+CREATE TABLE IF NOT EXISTS scheduler_state (
+    id           BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    paused       BOOLEAN NOT NULL DEFAULT FALSE,
+    paused_at    TIMESTAMP NULL,
+    pause_reason TEXT NULL,
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO scheduler_state (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS scheduler_state;
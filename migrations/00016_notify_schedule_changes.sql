@@ -0,0 +1,56 @@
+-- +goose Up
+-- Changes to subscriptions and to the scheduler state are announced on the schedule_changes channel, so
+-- running schedulers act on them without waiting for their next poll. Claims, lease renewals and completions
+-- made by the schedulers themselves touch leased rows only and are not announced.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed weather_subscriptions;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND (OLD.claimed_by IS NOT NULL OR NEW.claimed_by IS NOT NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'subscription',
+        'op', lower(TG_OP),
+        'id', changed.id,
+        'confirmed', changed.confirmed,
+        'next_run_at', changed.next_run_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_scheduler_state_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'scheduler',
+        'op', lower(TG_OP),
+        'paused', NEW.paused
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER weather_subscriptions_notify
+    AFTER INSERT OR UPDATE OR DELETE ON weather_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change();
+
+CREATE TRIGGER scheduler_state_notify
+    AFTER UPDATE ON scheduler_state
+    FOR EACH ROW EXECUTE FUNCTION notify_scheduler_state_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS scheduler_state_notify ON scheduler_state;
+DROP TRIGGER IF EXISTS weather_subscriptions_notify ON weather_subscriptions;
+DROP FUNCTION IF EXISTS notify_scheduler_state_change();
+DROP FUNCTION IF EXISTS notify_subscription_change();
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Emails a dry run kept from being sent, exactly as they would have been sent. They stay until an admin
+-- clears them, or until the recipient's data is erased.
+CREATE TABLE IF NOT EXISTS captured_emails (
+    id          BIGSERIAL PRIMARY KEY,
+    recipient   TEXT NOT NULL,
+    subject     TEXT NOT NULL,
+    body        TEXT NOT NULL,
+    headers     JSONB NOT NULL DEFAULT '{}',
+    captured_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_captured_emails_recipient ON captured_emails (LOWER(recipient), captured_at DESC);
+CREATE INDEX IF NOT EXISTS idx_captured_emails_captured_at ON captured_emails (captured_at DESC);
+
+-- Deliveries made in a dry run are logged as 'captured' rather than 'sent'
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'captured', 'retrying', 'failed', 'suppressed'));
+
+-- +goose Down
+DELETE FROM deliveries WHERE status = 'captured';
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'retrying', 'failed', 'suppressed'));
+
+DROP TABLE IF EXISTS captured_emails;
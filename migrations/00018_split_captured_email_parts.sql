@@ -0,0 +1,8 @@
+-- +goose Up
+-- Emails are multipart/alternative now: keep the HTML part and its plain-text alternative apart.
+ALTER TABLE captured_emails RENAME COLUMN body TO html_body;
+ALTER TABLE captured_emails ADD COLUMN IF NOT EXISTS text_body TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE captured_emails DROP COLUMN IF EXISTS text_body;
+ALTER TABLE captured_emails RENAME COLUMN html_body TO body;
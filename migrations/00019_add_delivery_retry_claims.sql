@@ -0,0 +1,12 @@
+-- +goose Up
+-- A scheduler instance claims the retries it resends until next_attempt_at and keeps renewing the claim while
+-- it sends them; claims another instance took over after they expired are no longer renewed.
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS claimed_by TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_claimed_by ON deliveries (claimed_by) WHERE claimed_by IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_deliveries_claimed_by;
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS claimed_by;
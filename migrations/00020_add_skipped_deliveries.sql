@@ -0,0 +1,12 @@
+-- +goose Up
+-- A retry the subscriber no longer wants when it is due, e.g. because they paused since the first attempt,
+-- is logged as 'skipped' instead of being sent
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'captured', 'retrying', 'failed', 'suppressed', 'skipped'));
+
+-- +goose Down
+DELETE FROM deliveries WHERE status = 'skipped';
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS deliveries_status_check,
+    ADD CONSTRAINT deliveries_status_check CHECK (status IN ('queued', 'sent', 'captured', 'retrying', 'failed', 'suppressed'));
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Unsubscribe links are signed with TOKEN_SECRET and carry the subscription id, so the stored unsubscribe token
+-- is no longer read. Links built from it before signed links were introduced stop working.
+ALTER TABLE weather_subscriptions
+    DROP COLUMN IF EXISTS unsubscribe_token;
+
+-- +goose Down
+ALTER TABLE weather_subscriptions
+    ADD COLUMN IF NOT EXISTS unsubscribe_token TEXT UNIQUE NULL;
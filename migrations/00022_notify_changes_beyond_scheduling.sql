@@ -0,0 +1,57 @@
+-- +goose Up
+-- An update is only left unannounced when it touches nothing but the scheduling columns that the schedulers
+-- write themselves. Previously any update of a leased row was skipped, so a pause, an unsubscribe-all or a
+-- fix by hand landing while a scheduler held the lease went unannounced.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed weather_subscriptions;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND to_jsonb(OLD) - 'claimed_by' - 'lease_expires_at' - 'next_run_at'
+                          = to_jsonb(NEW) - 'claimed_by' - 'lease_expires_at' - 'next_run_at' THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'subscription',
+        'op', lower(TG_OP),
+        'id', changed.id,
+        'confirmed', changed.confirmed,
+        'next_run_at', changed.next_run_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed weather_subscriptions;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND (OLD.claimed_by IS NOT NULL OR NEW.claimed_by IS NOT NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'subscription',
+        'op', lower(TG_OP),
+        'id', changed.id,
+        'confirmed', changed.confirmed,
+        'next_run_at', changed.next_run_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
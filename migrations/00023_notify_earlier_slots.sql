@@ -0,0 +1,60 @@
+-- +goose Up
+-- The quiet hours backlog is written by the dispatcher like the lease and the slot, so storing it is no longer
+-- announced. A slot moved earlier is announced, since the schedulers would otherwise only pick it up on their
+-- next poll; the dispatcher itself only ever moves slots later. An unset slot counts as due now.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed weather_subscriptions;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    IF TG_OP = 'UPDATE'
+       AND to_jsonb(OLD) - 'claimed_by' - 'lease_expires_at' - 'next_run_at' - 'quiet_backlog'
+         = to_jsonb(NEW) - 'claimed_by' - 'lease_expires_at' - 'next_run_at' - 'quiet_backlog'
+       AND NOT (OLD.next_run_at IS NOT NULL AND (NEW.next_run_at IS NULL OR NEW.next_run_at < OLD.next_run_at)) THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'subscription',
+        'op', lower(TG_OP),
+        'id', changed.id,
+        'confirmed', changed.confirmed,
+        'next_run_at', changed.next_run_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed weather_subscriptions;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND to_jsonb(OLD) - 'claimed_by' - 'lease_expires_at' - 'next_run_at'
+                          = to_jsonb(NEW) - 'claimed_by' - 'lease_expires_at' - 'next_run_at' THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('schedule_changes', json_build_object(
+        'source', 'subscription',
+        'op', lower(TG_OP),
+        'id', changed.id,
+        'confirmed', changed.confirmed,
+        'next_run_at', changed.next_run_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd